@@ -0,0 +1,142 @@
+package leopards
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// aggregate returns a selector computing the given aggregation function over the
+// rows matched by s, without its ORDER BY, LIMIT and OFFSET clauses. Queries with
+// GROUP BY, DISTINCT, HAVING or set operations are aggregated as a sub-query.
+func (s *Selector) aggregate(ctx context.Context, fn, column string) (*Selector, error) {
+	c := s.Clone().ClearOrder()
+	c.limit, c.offset, c.lock, c.cursor = nil, nil, nil, nil
+	if len(c.group) == 0 && c.having == nil && !c.distinct && len(c.setOps) == 0 {
+		c.selection = nil
+		return c.AppendSelectExpr(aggregateExpr(s.dialect, fn, column)), nil
+	}
+	// The wrapped selector is not executed itself, so the
+	// global scopes are applied on it before wrapping.
+	scoped, err := s.driver.resolveScopes(ctx, s.unscoped)
+	if err != nil {
+		return nil, err
+	}
+	c.scoped = scoped
+	if column != `*` {
+		column = cursorName(column)
+	}
+	w := Dialect(s.dialect).Select(s.driver).
+		FromTable(c.As(`aggregate`)).
+		AppendSelectExpr(aggregateExpr(s.dialect, fn, column)).
+		Unscoped()
+	return w, nil
+}
+
+// aggregateExpr returns the expression of an aggregation function.
+func aggregateExpr(dialect, fn, column string) Querier {
+	f := &Func{}
+	f.SetDialect(dialect)
+	f.byName(fn, column)
+	return Raw(f.String())
+}
+
+// CountRows returns the number of rows matched by the selector.
+//
+//	n, err := db.Query().From(`users`).Where(leopards.GT(`age`, 18)).CountRows(ctx)
+func (s *Selector) CountRows(ctx context.Context) (int64, error) {
+	c, err := s.aggregate(ctx, `COUNT`, `*`)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = c.ScanInto(ctx, &n)
+	return n, err
+}
+
+// Exists reports whether the selector matches any row.
+func (s *Selector) Exists(ctx context.Context) (bool, error) {
+	c := s.Clone()
+	c.cursor = nil
+	c.SelectExpr(Raw(`1`)).Limit(1)
+	var rows []int
+	if err := c.Scan(ctx, &rows); err != nil {
+		return false, err
+	}
+	return len(rows) > 0, nil
+}
+
+// Sum returns the SUM of the given column over the matched rows, or 0 if no rows matched.
+func (s *Selector) Sum(ctx context.Context, column string) (float64, error) {
+	return s.aggregateFloat(ctx, `SUM`, column)
+}
+
+// Avg returns the AVG of the given column over the matched rows, or 0 if no rows matched.
+func (s *Selector) Avg(ctx context.Context, column string) (float64, error) {
+	return s.aggregateFloat(ctx, `AVG`, column)
+}
+
+// Max scans the MAX of the given column over the matched rows into dest.
+//
+//	var last time.Time
+//	err := db.Query().From(`users`).Max(ctx, `created_at`, &last)
+func (s *Selector) Max(ctx context.Context, column string, dest any) error {
+	c, err := s.aggregate(ctx, `MAX`, column)
+	if err != nil {
+		return err
+	}
+	return c.ScanInto(ctx, dest)
+}
+
+// Min scans the MIN of the given column over the matched rows into dest.
+func (s *Selector) Min(ctx context.Context, column string, dest any) error {
+	c, err := s.aggregate(ctx, `MIN`, column)
+	if err != nil {
+		return err
+	}
+	return c.ScanInto(ctx, dest)
+}
+
+func (s *Selector) aggregateFloat(ctx context.Context, fn, column string) (float64, error) {
+	c, err := s.aggregate(ctx, fn, column)
+	if err != nil {
+		return 0, err
+	}
+	var v float64
+	err = c.ScanInto(ctx, &v)
+	return v, err
+}
+
+// Pluck scans the values of a single column into dest, a pointer to a slice of scalars.
+//
+//	var names []string
+//	err := db.Query().From(`users`).Pluck(ctx, `name`, &names)
+func (s *Selector) Pluck(ctx context.Context, column string, dest any) error {
+	if t := reflect.TypeOf(dest); t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Slice {
+		return errors.New(`Pluck: expect pointer to slice as argument`)
+	}
+	return s.Clone().Select(column).Scan(ctx, dest)
+}
+
+// ScanInto scans the first row of a single-value query into dest, a pointer to
+// a scalar (or struct). It returns sql.ErrNoRows if the query returned no rows.
+//
+//	var name string
+//	err := db.Query().Select(`name`).From(`users`).Where(leopards.EQ(`id`, 1)).ScanInto(ctx, &name)
+func (s *Selector) ScanInto(ctx context.Context, dest any) error {
+	t := reflect.TypeOf(dest)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return errors.New(`ScanInto: non-pointer of dest`)
+	}
+	rows := reflect.New(reflect.SliceOf(t.Elem()))
+	if err := s.Scan(ctx, rows.Interface()); err != nil {
+		return err
+	}
+	if rows.Elem().Len() == 0 {
+		return fmt.Errorf("ScanInto: %w", sql.ErrNoRows)
+	}
+	reflect.ValueOf(dest).Elem().Set(rows.Elem().Index(0))
+	return nil
+}
@@ -0,0 +1,97 @@
+package leopards
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAggregateSQL(t *testing.T) {
+	ctx := context.Background()
+	s := Dialect(MySQL).Select(nil).From(`users`).Where(GT(`age`, 18)).OrderBy(`id`).Limit(10)
+	c, err := s.aggregate(ctx, `COUNT`, `*`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSQL(t, c, "SELECT COUNT(*) FROM `users` WHERE `age` > ?", 18)
+
+	c, err = s.aggregate(ctx, `SUM`, `age`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSQL(t, c, "SELECT SUM(`age`) FROM `users` WHERE `age` > ?", 18)
+
+	// The selector itself is not modified.
+	assertSQL(t, s, "SELECT * FROM `users` WHERE `age` > ? ORDER BY `id` LIMIT 10", 18)
+}
+
+func TestAggregates(t *testing.T) {
+	db := openUsers(t)
+	ctx := context.Background()
+	users := func() *Selector { return db.Query().From(`users`) }
+
+	n, err := users().Where(GTE(`age`, 20)).CountRows(ctx)
+	if err != nil || n != 4 {
+		t.Errorf("CountRows = %d, %v, want 4", n, err)
+	}
+	n, err = db.Query().Select(`age`).From(`users`).GroupBy(`age`).CountRows(ctx)
+	if err != nil || n != 4 {
+		t.Errorf("grouped CountRows = %d, %v, want 4", n, err)
+	}
+	ok, err := users().Where(EQ(`name`, `c`)).Exists(ctx)
+	if err != nil || !ok {
+		t.Errorf("Exists = %v, %v, want true", ok, err)
+	}
+	ok, err = users().Where(EQ(`name`, `z`)).Exists(ctx)
+	if err != nil || ok {
+		t.Errorf("Exists = %v, %v, want false", ok, err)
+	}
+	sum, err := users().Sum(ctx, `age`)
+	if err != nil || sum != 120 {
+		t.Errorf("Sum = %v, %v, want 120", sum, err)
+	}
+	avg, err := users().Avg(ctx, `age`)
+	if err != nil || avg != 24 {
+		t.Errorf("Avg = %v, %v, want 24", avg, err)
+	}
+	sum, err = users().Where(GT(`age`, 100)).Sum(ctx, `age`)
+	if err != nil || sum != 0 {
+		t.Errorf("Sum of no rows = %v, %v, want 0", sum, err)
+	}
+	var max, min int
+	if err := users().Max(ctx, `age`, &max); err != nil || max != 40 {
+		t.Errorf("Max = %v, %v, want 40", max, err)
+	}
+	if err := users().Min(ctx, `age`, &min); err != nil || min != 10 {
+		t.Errorf("Min = %v, %v, want 10", min, err)
+	}
+
+	var names []string
+	if err := users().Where(EQ(`age`, 20)).OrderBy(`id`).Pluck(ctx, `name`, &names); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`b`, `c`}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Pluck = %v, want %v", names, want)
+	}
+	var ids []int
+	if err := users().OrderBy(Desc(`id`)).Limit(2).Pluck(ctx, `id`, &ids); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{5, 4}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Pluck = %v, want %v", ids, want)
+	}
+	if err := users().Pluck(ctx, `id`, ids); err == nil {
+		t.Error("Pluck into a slice value: expect an error")
+	}
+
+	var name string
+	if err := db.Query().Select(`name`).From(`users`).Where(EQ(`id`, 4)).ScanInto(ctx, &name); err != nil || name != `d` {
+		t.Errorf("ScanInto = %q, %v, want d", name, err)
+	}
+	err = db.Query().Select(`name`).From(`users`).Where(EQ(`id`, 9)).ScanInto(ctx, &name)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("ScanInto = %v, want sql.ErrNoRows", err)
+	}
+}
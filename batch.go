@@ -0,0 +1,175 @@
+package leopards
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type (
+	// batch holds the configuration of InsertBuilder.SaveInBatches.
+	batch struct {
+		tx   bool
+		copy bool
+	}
+
+	// BatchOption allows configuring the batch insertion
+	// using functional options.
+	BatchOption func(*batch)
+)
+
+// BatchInTx runs all batches in one transaction, rolled back if any batch
+// fails. It is ignored if the builder was created from a transaction.
+func BatchInTx() BatchOption {
+	return func(b *batch) {
+		b.tx = true
+	}
+}
+
+// BatchCopyIn loads the rows with the PostgreSQL COPY protocol (lib/pq CopyIn)
+// instead of INSERT statements. It always runs in a transaction, and does not
+// support the ON CONFLICT and RETURNING clauses. Supported by PostgreSQL.
+func BatchCopyIn() BatchOption {
+	return func(b *batch) {
+		b.copy = true
+	}
+}
+
+// SaveInBatches inserts the values of the builder in chunks of size rows, to
+// stay under the MySQL `max_allowed_packet` and the PostgreSQL limit of 65535
+// parameters per statement, and returns the total number of rows affected.
+//
+//	n, err := db.Insert().Table(`users`).Columns(`name`, `age`).
+//		Values(`a`, 1).
+//		Values(`b`, 2).
+//		SaveInBatches(ctx, 1000, leopards.BatchInTx())
+func (i *InsertBuilder) SaveInBatches(ctx context.Context, size int, opts ...BatchOption) (int64, error) {
+	if size <= 0 {
+		return 0, fmt.Errorf("SaveInBatches: invalid batch size %d", size)
+	}
+	var cfg batch
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.copy {
+		return i.copyIn(ctx)
+	}
+
+	db := i.driver
+	if cfg.tx && db.tx == nil {
+		tx, err := db.TX(ctx)
+		if err != nil {
+			return 0, err
+		}
+		n, err := i.saveInBatches(ctx, tx, size)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, err
+		}
+		return n, tx.Commit(ctx)
+	}
+	return i.saveInBatches(ctx, db, size)
+}
+
+// saveInBatches executes the chunks of the builder on the given DB.
+func (i *InsertBuilder) saveInBatches(ctx context.Context, db *DB, size int) (int64, error) {
+	var total int64
+	for lo := 0; lo < len(i.values); lo += size {
+		hi := lo + size
+		if hi > len(i.values) {
+			hi = len(i.values)
+		}
+		chunk := *i
+		chunk.values = i.values[lo:hi]
+		chunk.driver = db
+		res, err := chunk.Save(ctx)
+		if err != nil {
+			return total, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// copyIn loads the values of the builder with the COPY protocol.
+func (i *InsertBuilder) copyIn(ctx context.Context) (int64, error) {
+	switch {
+	case !i.postgres():
+		return 0, errors.New("SaveInBatches: COPY is supported only by PostgreSQL")
+	case i.conflict != nil || len(i.returning) > 0:
+		return 0, errors.New("SaveInBatches: COPY does not support ON CONFLICT and RETURNING")
+	}
+	scoped, err := i.driver.resolveScopes(ctx, i.unscoped)
+	if err != nil {
+		return 0, err
+	}
+	i.scoped = scoped
+
+	for _, iter := range i.driver.beforeInsert {
+		iter(i)
+	}
+
+	if err = i.Err(); err != nil {
+		return 0, err
+	}
+
+	columns, values := i.scopedValues()
+	statement := pq.CopyInSchema(i.schema, i.table, columns...)
+	if i.schema == `` {
+		statement = pq.CopyIn(i.table, columns...)
+	}
+
+	if i.driver.debug {
+		fmt.Printf("%s: %s [%d rows]\n", time.Now().Format(`2006-01-02 15:04:05`), strings.TrimSpace(statement), len(values))
+	}
+
+	tx := i.driver.tx
+	if tx == nil {
+		if tx, err = i.driver.driver.BeginTx(ctx, nil); err != nil {
+			return 0, err
+		}
+	}
+	res, err := copyRows(ctx, tx, statement, values)
+	if i.driver.tx == nil {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}
+	// Nothing was loaded, and there is no result for the interceptors.
+	if err != nil {
+		return 0, err
+	}
+	i.driver.invalidate(ctx, i.table)
+
+	for _, iter := range i.driver.afterInsert {
+		iter(i, res)
+	}
+
+	return res.RowsAffected()
+}
+
+// copyRows executes the COPY statement with the given rows.
+func copyRows(ctx context.Context, tx *sql.Tx, statement string, values [][]any) (sql.Result, error) {
+	stmt, err := tx.PrepareContext(ctx, statement)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	for _, row := range values {
+		if _, err = stmt.ExecContext(ctx, row...); err != nil {
+			return nil, err
+		}
+	}
+	return stmt.ExecContext(ctx)
+}
@@ -0,0 +1,72 @@
+package leopards
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSaveInBatches(t *testing.T) {
+	db := openSQLite(t, "CREATE TABLE `users` (`id` integer PRIMARY KEY, `name` text UNIQUE)")
+	var statements []string
+	db.InterceptorsInsert(func(i *InsertBuilder) {
+		statement, _, _ := i.ToSQL()
+		statements = append(statements, statement)
+	})
+	ctx := context.Background()
+	ins := db.Insert().Table(`users`).Columns(`name`)
+	for _, name := range []string{`a`, `b`, `c`, `d`, `e`} {
+		ins.Values(name)
+	}
+	n, err := ins.SaveInBatches(ctx, 2)
+	if err != nil || n != 5 {
+		t.Fatalf("SaveInBatches = %d, %v, want 5", n, err)
+	}
+	want := []string{
+		"INSERT INTO `users` (`name`) VALUES (?), (?)",
+		"INSERT INTO `users` (`name`) VALUES (?), (?)",
+		"INSERT INTO `users` (`name`) VALUES (?)",
+	}
+	if len(statements) != len(want) {
+		t.Fatalf("statements = %q, want %q", statements, want)
+	}
+	for i := range want {
+		if statements[i] != want[i] {
+			t.Errorf("statement %d = %s, want %s", i, statements[i], want[i])
+		}
+	}
+
+	// The third batch fails on the unique name, and BatchInTx rolls back the others.
+	ins = db.Insert().Table(`users`).Columns(`name`).Values(`f`).Values(`g`).Values(`a`)
+	if _, err := ins.SaveInBatches(ctx, 1, BatchInTx()); err == nil {
+		t.Fatal("SaveInBatches: expect a unique constraint error")
+	}
+	count, err := db.Query().From(`users`).CountRows(ctx)
+	if err != nil || count != 5 {
+		t.Errorf("CountRows = %d, %v, want 5 after the rollback", count, err)
+	}
+
+	if _, err := ins.SaveInBatches(ctx, 0); err == nil {
+		t.Error("SaveInBatches(0): expect an error")
+	}
+	if _, err := ins.SaveInBatches(ctx, 10, BatchCopyIn()); err == nil {
+		t.Error("BatchCopyIn on SQLite: expect an error")
+	}
+}
+
+// A failed COPY does not run the afterInsert interceptors with a nil result.
+func TestCopyInError(t *testing.T) {
+	db := openSQLite(t)
+	// SQLite rejects the COPY statement of the Postgres dialect.
+	db.dialect = Postgres
+	called := false
+	db.InterceptorsAfterInsert(func(_ *InsertBuilder, res any) {
+		called = true
+	})
+	_, err := db.Insert().Table(`users`).Columns(`name`).Values(`a`).SaveInBatches(context.Background(), 10, BatchCopyIn())
+	if err == nil {
+		t.Fatal("SaveInBatches: expect a COPY error")
+	}
+	if called {
+		t.Error("afterInsert interceptors called for a failed COPY")
+	}
+}
@@ -0,0 +1,393 @@
+package leopards
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCacheMiss is returned by Cache.Get for missing or expired keys.
+var ErrCacheMiss = errors.New("leopards: cache miss")
+
+// Cache is the storage of query results cached with Selector.Cache.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value of the key, or ErrCacheMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set sets the value of the key. A zero ttl means no expiration.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete deletes the key.
+	Delete(ctx context.Context, key string) error
+}
+
+// UseCache sets the cache of the query results of selectors configured with
+// Selector.Cache (nil disables it). The results are invalidated by table when
+// InsertBuilder, UpdateBuilder or DeleteBuilder modify the table through the
+// DB, or after the commit of the transaction modifying it.
+//
+//	db.UseCache(leopards.NewMemoryCache(1024))
+func (b *DB) UseCache(c Cache) {
+	b.cache = nil
+	if c != nil {
+		b.cache = &queryCache{Cache: c, calls: make(map[string]*cacheCall)}
+	}
+}
+
+// InvalidateCache invalidates the cached results of queries reading the
+// given tables, for example, after they were modified with raw SQL.
+func (b *DB) InvalidateCache(ctx context.Context, tables ...string) error {
+	if b.cache == nil {
+		return nil
+	}
+	for _, t := range tables {
+		if err := b.cache.Delete(ctx, tableKey(t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invalidate invalidates the cached results of the table modified by a statement.
+// In transactions, the table is invalidated after the commit.
+func (b *DB) invalidate(ctx context.Context, table string) {
+	switch {
+	case b.cache == nil || table == ``:
+	case b.tx != nil:
+		if b.dirty != nil {
+			b.dirty[table] = struct{}{}
+		}
+	default:
+		if err := b.cache.Delete(ctx, tableKey(table)); err != nil && b.debug {
+			fmt.Printf("%s: cache: %v\n", time.Now().Format(`2006-01-02 15:04:05`), err)
+		}
+	}
+}
+
+// Cache caches the results of the selector for the given duration (0 for no
+// expiration), if a cache was set with DB.UseCache. Results are keyed by the
+// statement, its arguments and the versions of the tables in its FROM, JOIN
+// and set operation clauses. Concurrent executions of the same query are
+// deduplicated. Queries in transactions or with locking clauses are not cached.
+//
+//	err := db.Query().From(`users`).Where(leopards.EQ(`id`, 1)).Cache(time.Minute).Scan(ctx, &user)
+//
+// Note: tables in sub-queries of predicates are not tracked, and results must be
+// encodable with encoding/gob, otherwise they are not cached.
+func (s *Selector) Cache(ttl time.Duration) *Selector {
+	s.ttl = &ttl
+	return s
+}
+
+// cacheable reports if the results of the selector are cached.
+func (s *Selector) cacheable() bool {
+	return s.ttl != nil && s.driver != nil && s.driver.cache != nil && s.driver.tx == nil && s.lock == nil
+}
+
+// cacheTables returns the tables read by the selector.
+func (s *Selector) cacheTables() []string {
+	var tables []string
+	views := append([]TableView{}, s.from...)
+	for _, j := range s.joins {
+		views = append(views, j.table)
+	}
+	for _, op := range s.setOps {
+		views = append(views, op.TableView)
+	}
+	for _, v := range views {
+		switch v := v.(type) {
+		case *SelectTable:
+			if !contains(tables, v.name) {
+				tables = append(tables, v.name)
+			}
+		case *Selector:
+			for _, t := range v.cacheTables() {
+				if !contains(tables, t) {
+					tables = append(tables, t)
+				}
+			}
+		}
+	}
+	return tables
+}
+
+// scanCache scans the results of the statement into dest from the cache,
+// or executes the statement and caches its results.
+func (s *Selector) scanCache(ctx context.Context, statement string, args []any, dest any) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New(`ScanRow: non-pointer of dest`)
+	}
+	c := s.driver.cache
+	key, err := c.key(ctx, s.dialect, statement, args, s.cacheTables())
+	if err != nil {
+		return s.driver.scanQuery(ctx, statement, args, dest)
+	}
+	if data, err := c.Get(ctx, key); err == nil {
+		if err := decodeResult(data, rv); err == nil {
+			return nil
+		}
+	}
+	var fresh reflect.Value
+	data, err, shared := c.do(key, func() ([]byte, error) {
+		fresh = reflect.New(rv.Type().Elem())
+		if err := s.driver.scanQuery(ctx, statement, args, fresh.Interface()); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(fresh.Interface()); err != nil {
+			return nil, &uncacheableError{err}
+		}
+		_ = c.Set(ctx, key, buf.Bytes(), *s.ttl)
+		return buf.Bytes(), nil
+	})
+	var uerr *uncacheableError
+	switch {
+	case !shared && fresh.IsValid() && (err == nil || errors.As(err, &uerr)):
+		rv.Elem().Set(fresh.Elem())
+		return nil
+	case errors.As(err, &uerr):
+		return s.driver.scanQuery(ctx, statement, args, dest)
+	case err != nil:
+		return err
+	}
+	return decodeResult(data, rv)
+}
+
+// scanQuery executes the query and scans its rows into dest.
+func (b *DB) scanQuery(ctx context.Context, statement string, args []any, dest any) error {
+	rows, err := b.queryContext(ctx, statement, args)
+	if err != nil {
+		return err
+	}
+	if err = b.Scan(rows, dest); err != nil {
+		_ = rows.Close()
+	}
+	return err
+}
+
+// decodeResult decodes a cached result into the pointer rv.
+func decodeResult(data []byte, rv reflect.Value) error {
+	v := reflect.New(rv.Type().Elem())
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(v.Interface()); err != nil {
+		return err
+	}
+	rv.Elem().Set(v.Elem())
+	return nil
+}
+
+// uncacheableError is returned for results that can't be encoded.
+type uncacheableError struct{ err error }
+
+func (e *uncacheableError) Error() string { return "cache: " + e.err.Error() }
+
+// queryCache wraps the Cache of a DB with the versions
+// of the tables and the deduplication of queries.
+type queryCache struct {
+	Cache
+	mu    sync.Mutex
+	calls map[string]*cacheCall
+}
+
+// cacheCall is an in-flight execution of a cached query.
+type cacheCall struct {
+	wg   sync.WaitGroup
+	data []byte
+	err  error
+}
+
+// do executes fn once for concurrent calls with the same key, and reports
+// whether the result was shared by another call.
+func (c *queryCache) do(key string, fn func() ([]byte, error)) ([]byte, error, bool) {
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		call.wg.Wait()
+		return call.data, call.err, true
+	}
+	call := &cacheCall{}
+	call.wg.Add(1)
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.data, call.err = fn()
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+	call.wg.Done()
+	return call.data, call.err, false
+}
+
+// key returns the cache key of the query. The key holds the versions of the
+// tables, which are replaced when the tables are invalidated.
+func (c *queryCache) key(ctx context.Context, dialect, statement string, args []any, tables []string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", dialect, statement)
+	for _, arg := range args {
+		if v, ok := arg.(driver.Valuer); ok {
+			if value, err := v.Value(); err == nil {
+				arg = value
+			}
+		}
+		fmt.Fprintf(h, "%T=%v\x00", arg, arg)
+	}
+	for _, t := range tables {
+		version, err := c.version(ctx, t)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s=%s\x00", t, version)
+	}
+	return "leopards:query:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+var versionSeq uint64
+
+// version returns the version of the table, or creates a new one.
+func (c *queryCache) version(ctx context.Context, table string) (string, error) {
+	switch data, err := c.Get(ctx, tableKey(table)); {
+	case err == nil:
+		return string(data), nil
+	case !errors.Is(err, ErrCacheMiss):
+		return "", err
+	}
+	version := fmt.Sprintf("%x.%x", time.Now().UnixNano(), atomic.AddUint64(&versionSeq, 1))
+	return version, c.Set(ctx, tableKey(table), []byte(version), 0)
+}
+
+func tableKey(table string) string {
+	return "leopards:table:" + table
+}
+
+// memoryCache is an in-memory LRU Cache.
+type memoryCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns an in-memory Cache holding up to size
+// entries, evicting the least recently used entries first.
+func NewMemoryCache(size int) Cache {
+	return &memoryCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	entry := e.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.ll.Remove(e)
+		delete(c.items, key)
+		return nil, ErrCacheMiss
+	}
+	c.ll.MoveToFront(e)
+	return entry.value, nil
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := &memoryEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		e.Value = entry
+		c.ll.MoveToFront(e)
+		return nil
+	}
+	c.items[key] = c.ll.PushFront(entry)
+	for c.size > 0 && c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+func (c *memoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.Remove(e)
+		delete(c.items, key)
+	}
+	return nil
+}
+
+// RedisClient is the subset of the commands of a Redis client used by the Redis
+// cache. Get must return ErrCacheMiss, or an error with the "redis: nil" message
+// (e.g. redis.Nil of go-redis), for missing keys.
+//
+//	type client struct{ *redis.Client }
+//
+//	func (c client) Get(ctx context.Context, key string) (string, error) {
+//		return c.Client.Get(ctx, key).Result()
+//	}
+//
+//	func (c client) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+//		return c.Client.Set(ctx, key, value, ttl).Err()
+//	}
+//
+//	func (c client) Del(ctx context.Context, keys ...string) error {
+//		return c.Client.Del(ctx, keys...).Err()
+//	}
+type RedisClient interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	Del(ctx context.Context, keys ...string) error
+}
+
+// redisCache is a Cache stored in Redis.
+type redisCache struct {
+	client RedisClient
+}
+
+// NewRedisCache returns a Cache storing the results in Redis. Unlike the memory
+// cache, its entries and invalidations are shared by all processes using it.
+//
+//	db.UseCache(leopards.NewRedisCache(client{redis.NewClient(&redis.Options{Addr: `localhost:6379`})}))
+func NewRedisCache(client RedisClient) Cache {
+	return &redisCache{client: client}
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	v, err := c.client.Get(ctx, key)
+	switch {
+	case err == nil:
+		return []byte(v), nil
+	case errors.Is(err, ErrCacheMiss) || err.Error() == "redis: nil":
+		return nil, ErrCacheMiss
+	default:
+		return nil, err
+	}
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, string(value), ttl)
+}
+
+func (c *redisCache) Delete(ctx context.Context, key string) error {
+	return c.client.Del(ctx, key)
+}
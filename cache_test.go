@@ -0,0 +1,210 @@
+package leopards
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(2)
+	if _, err := c.Get(ctx, `a`); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get = %v, want ErrCacheMiss", err)
+	}
+	_ = c.Set(ctx, `a`, []byte(`1`), 0)
+	_ = c.Set(ctx, `b`, []byte(`2`), 0)
+	// a is the most recently used entry, and b is evicted by c.
+	if v, err := c.Get(ctx, `a`); err != nil || string(v) != `1` {
+		t.Errorf("Get = %s, %v, want 1", v, err)
+	}
+	_ = c.Set(ctx, `c`, []byte(`3`), 0)
+	if _, err := c.Get(ctx, `b`); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get evicted = %v, want ErrCacheMiss", err)
+	}
+	_ = c.Set(ctx, `a`, []byte(`4`), 0)
+	if v, err := c.Get(ctx, `a`); err != nil || string(v) != `4` {
+		t.Errorf("Get = %s, %v, want 4", v, err)
+	}
+	_ = c.Delete(ctx, `a`)
+	if _, err := c.Get(ctx, `a`); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get deleted = %v, want ErrCacheMiss", err)
+	}
+	_ = c.Set(ctx, `d`, []byte(`5`), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, err := c.Get(ctx, `d`); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get expired = %v, want ErrCacheMiss", err)
+	}
+}
+
+// redisStub is a RedisClient reporting missing keys like go-redis.
+type redisStub struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func (r *redisStub) Get(_ context.Context, key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.m[key]
+	if !ok {
+		return "", errors.New("redis: nil")
+	}
+	return v, nil
+}
+
+func (r *redisStub) Set(_ context.Context, key, value string, _ time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.m[key] = value
+	return nil
+}
+
+func (r *redisStub) Del(_ context.Context, keys ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range keys {
+		delete(r.m, k)
+	}
+	return nil
+}
+
+func TestRedisCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewRedisCache(&redisStub{m: make(map[string]string)})
+	if _, err := c.Get(ctx, `a`); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get = %v, want ErrCacheMiss", err)
+	}
+	_ = c.Set(ctx, `a`, []byte(`1`), time.Minute)
+	if v, err := c.Get(ctx, `a`); err != nil || string(v) != `1` {
+		t.Errorf("Get = %s, %v, want 1", v, err)
+	}
+	_ = c.Delete(ctx, `a`)
+	if _, err := c.Get(ctx, `a`); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get deleted = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestSelectorCache(t *testing.T) {
+	db := openSQLite(t,
+		"CREATE TABLE `users` (`id` integer PRIMARY KEY, `name` text)",
+		"CREATE TABLE `pets` (`id` integer PRIMARY KEY, `owner_id` integer)",
+		"INSERT INTO `users` (`name`) VALUES ('a')",
+	)
+	db.UseCache(NewRedisCache(&redisStub{m: make(map[string]string)}))
+	ctx := context.Background()
+	names := func() []string {
+		t.Helper()
+		var names []string
+		if err := db.Query().Select(`name`).From(`users`).OrderBy(`id`).Cache(time.Minute).Scan(ctx, &names); err != nil {
+			t.Fatal(err)
+		}
+		return names
+	}
+	count := func() int {
+		t.Helper()
+		var n int
+		s := db.Query().Select(`COUNT(*)`).From(`users`)
+		pets := Table(`pets`)
+		s.Join(pets).On(s.C(`id`), pets.C(`owner_id`))
+		if err := s.Cache(time.Minute).Scan(ctx, &n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if got := names(); len(got) != 1 || count() != 0 {
+		t.Fatalf("names = %v", got)
+	}
+
+	// Raw statements do not invalidate the cache.
+	if _, err := db.Exec(ctx, "INSERT INTO `users` (`name`) VALUES ('b')"); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(); len(got) != 1 {
+		t.Errorf("cached names = %v, want [a]", got)
+	}
+	if err := db.InvalidateCache(ctx, `users`); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(); len(got) != 2 {
+		t.Errorf("names after InvalidateCache = %v, want [a b]", got)
+	}
+
+	// The builders invalidate the tables they modify, including joined tables.
+	if _, err := db.Insert().Table(`users`).Columns(`name`).Values(`c`).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(); len(got) != 3 {
+		t.Errorf("names after Insert = %v, want [a b c]", got)
+	}
+	if _, err := db.Insert().Table(`pets`).Columns(`owner_id`).Values(1).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 1 {
+		t.Errorf("count after Insert into the joined table = %d, want 1", n)
+	}
+
+	// Transactions invalidate the tables after the commit, and are not cached.
+	tx, err := db.TX(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Delete().Table(`users`).Where(EQ(`name`, `c`)).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var in []string
+	if err := tx.Query().Select(`name`).From(`users`).Cache(time.Minute).Scan(ctx, &in); err != nil || len(in) != 2 {
+		t.Errorf("names in transaction = %v, %v, want [a b]", in, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(); len(got) != 2 {
+		t.Errorf("names after Commit = %v, want [a b]", got)
+	}
+}
+
+func TestCacheDo(t *testing.T) {
+	c := &queryCache{Cache: NewMemoryCache(0), calls: make(map[string]*cacheCall)}
+	var (
+		calls   int32
+		shared  int32
+		wg      sync.WaitGroup
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.do(`k`, func() ([]byte, error) {
+			atomic.AddInt32(&calls, 1)
+			close(started)
+			<-release
+			return []byte(`v`), nil
+		})
+	}()
+	<-started
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err, ok := c.do(`k`, func() ([]byte, error) {
+				atomic.AddInt32(&calls, 1)
+				return []byte(`v`), nil
+			})
+			if ok && err == nil && string(data) == `v` {
+				atomic.AddInt32(&shared, 1)
+			}
+		}()
+	}
+	// Let the calls join the in-flight call.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 || shared != 5 {
+		t.Errorf("calls = %d, shared = %d, want 1 call shared by 5", calls, shared)
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var update = flag.Bool(`update`, false, `update the golden files of testdata`)
+
+// execute runs the leopards command with the given arguments, and returns
+// its output. The flags of the commands are reset to their defaults before.
+func execute(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var reset func(*cobra.Command)
+	reset = func(c *cobra.Command) {
+		for _, fs := range []*pflag.FlagSet{c.Flags(), c.PersistentFlags()} {
+			fs.VisitAll(func(f *pflag.Flag) {
+				if v, ok := f.Value.(pflag.SliceValue); ok {
+					_ = v.Replace(nil)
+				} else {
+					_ = f.Value.Set(f.DefValue)
+				}
+				f.Changed = false
+			})
+		}
+		for _, sub := range c.Commands() {
+			reset(sub)
+		}
+	}
+	reset(RootCMD)
+	var out bytes.Buffer
+	RootCMD.SetOut(&out)
+	RootCMD.SetErr(&out)
+	RootCMD.SetArgs(args)
+	err := RootCMD.Execute()
+	return out.String(), err
+}
+
+// generateDDL runs the ddl command of the dialect on the testdata files, and
+// returns the generated file of the model package.
+func generateDDL(t *testing.T, dialect string, files []string, args ...string) []byte {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), `model`, `model.go`)
+	cmdArgs := []string{`ddl`, `-d`, dialect, `-o`, out}
+	for _, f := range files {
+		cmdArgs = append(cmdArgs, filepath.Join(`testdata`, f))
+	}
+	if output, err := execute(t, append(cmdArgs, args...)...); err != nil {
+		t.Fatalf("ddl %v: %v\n%s", files, err, output)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// golden compares the generated code with the golden file of testdata,
+// or updates the file with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join(`testdata`, name+`.golden`)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is out of date, run go test -update:\n got:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes(t *testing.T) {
+	golden(t, `types`, generateDDL(t, `mysql`, []string{`types.sql`}, `--config`, `testdata/types.yaml`))
+}
+
+func TestGenerateUnknownType(t *testing.T) {
+	output, err := execute(t, `ddl`, `testdata/types.sql`, `-o`, t.TempDir()+`/model/model.go`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "warning: unknown type uuid of column products.sku, using string\n"; output != want {
+		t.Errorf("output: %q, want %q", output, want)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{`--tags`, `xml`},
+		{`--config`, `testdata/missing.yaml`},
+	} {
+		_, err := execute(t, append([]string{`ddl`, `testdata/types.sql`, `-o`, t.TempDir() + `/model/model.go`}, args...)...)
+		if err == nil || !strings.Contains(err.Error(), strings.TrimPrefix(args[1], `testdata/`)) {
+			t.Errorf("ddl %v: %v, want an error", args, err)
+		}
+	}
+}
@@ -0,0 +1,1438 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/liqiongfan/leopards"
+	"github.com/spf13/cobra"
+)
+
+// Kinds of the DDL tokens.
+const (
+	tokIdent  = iota // identifier or keyword.
+	tokQuoted        // quoted identifier.
+	tokString        // string literal.
+	tokNumber        // numeric literal.
+	tokPunct         // punctuation or operator.
+)
+
+// ddlToken is a token of a DDL file.
+type ddlToken struct {
+	kind       int
+	text       string // unquoted text of identifiers and strings.
+	start, end int    // offsets of the token in the source.
+	line       int
+}
+
+// lexDDL splits the DDL source into tokens, skipping the comments. Double quotes
+// delimit strings in MySQL and identifiers in PostgreSQL.
+func lexDDL(src, dialect string) ([]ddlToken, error) {
+	var (
+		tokens []ddlToken
+		line   = 1
+	)
+	for i := 0; i < len(src); {
+		c := src[i]
+		start, startLine := i, line
+		switch {
+		case c == '\n':
+			line++
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+			continue
+		case c == '-' && strings.HasPrefix(src[i:], `--`), c == '#' && dialect == leopards.MySQL:
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && strings.HasPrefix(src[i:], `/*`):
+			end := strings.Index(src[i+2:], `*/`)
+			if end == -1 {
+				return nil, fmt.Errorf(`%d: unterminated comment`, line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+			continue
+		case c == '\'' || c == '"' && dialect == leopards.MySQL:
+			text, n, err := lexQuoted(src[i:], c, dialect == leopards.MySQL)
+			if err != nil {
+				return nil, fmt.Errorf(`%d: %w`, line, err)
+			}
+			line += strings.Count(src[i:i+n], "\n")
+			i += n
+			tokens = append(tokens, ddlToken{kind: tokString, text: text, start: start, end: i, line: startLine})
+		case c == '`' || c == '"':
+			text, n, err := lexQuoted(src[i:], c, false)
+			if err != nil {
+				return nil, fmt.Errorf(`%d: %w`, line, err)
+			}
+			line += strings.Count(src[i:i+n], "\n")
+			i += n
+			tokens = append(tokens, ddlToken{kind: tokQuoted, text: text, start: start, end: i, line: startLine})
+		case c == '$' && dialect == leopards.Postgres:
+			// Dollar-quoted strings, e.g. the bodies of the functions.
+			tag := src[i : i+1+strings.IndexByte(src[i+1:], '$')+1]
+			if !isDollarTag(tag) {
+				i++
+				tokens = append(tokens, ddlToken{kind: tokPunct, text: `$`, start: start, end: i, line: startLine})
+				continue
+			}
+			end := strings.Index(src[i+len(tag):], tag)
+			if end == -1 {
+				return nil, fmt.Errorf(`%d: unterminated string %s`, line, tag)
+			}
+			n := len(tag) + end + len(tag)
+			line += strings.Count(src[i:i+n], "\n")
+			tokens = append(tokens, ddlToken{kind: tokString, text: src[i+len(tag) : i+len(tag)+end], start: start, end: i + n, line: startLine})
+			i += n
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' || src[i] == 'e' || src[i] == 'E') {
+				i++
+			}
+			tokens = append(tokens, ddlToken{kind: tokNumber, text: src[start:i], start: start, end: i, line: startLine})
+		case isIdentByte(c):
+			for i < len(src) && (isIdentByte(src[i]) || src[i] >= '0' && src[i] <= '9' || src[i] == '$') {
+				i++
+			}
+			tokens = append(tokens, ddlToken{kind: tokIdent, text: src[start:i], start: start, end: i, line: startLine})
+		default:
+			i++
+			if c == ':' && i < len(src) && src[i] == ':' {
+				i++
+			}
+			tokens = append(tokens, ddlToken{kind: tokPunct, text: src[start:i], start: start, end: i, line: startLine})
+		}
+	}
+	return tokens, nil
+}
+
+// lexQuoted returns the text of the quoted string or identifier at the
+// beginning of s, and its length. Doubled quotes are unescaped, and so are
+// the backslash escapes if backslash is true.
+func lexQuoted(s string, quote byte, backslash bool) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && backslash && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			default:
+				b.WriteByte(s[i])
+			}
+		case c == quote && i+1 < len(s) && s[i+1] == quote:
+			b.WriteByte(quote)
+			i++
+		case c == quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return ``, 0, fmt.Errorf(`unterminated %c`, quote)
+}
+
+// isIdentByte reports if the byte starts an identifier. Bytes of multi-byte
+// characters are accepted as well.
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// isDollarTag reports if s is the tag of a dollar-quoted string, e.g. $$ or $body$.
+func isDollarTag(s string) bool {
+	if len(s) < 2 || s[len(s)-1] != '$' {
+		return false
+	}
+	for i, c := range s[1 : len(s)-1] {
+		if !(c == '_' || unicode.IsLetter(c) || i > 0 && unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ddlTable is a table defined by the DDL statements.
+type ddlTable struct {
+	schema      string
+	name        string
+	comment     string
+	columns     []*ddlColumn
+	indexes     []*IndexInfo
+	foreignKeys []*ForeignKeyInfo
+}
+
+// ddlColumn is a column defined by the DDL statements.
+type ddlColumn struct {
+	name      string
+	typ       string  // normalized column type, e.g. varchar(255).
+	def       *string // default value expression.
+	literal   *string // unquoted value of the string literal default values.
+	comment   string
+	notNull   bool
+	auto      bool   // auto_increment, serial or identity column.
+	onUpdate  string // ON UPDATE expression of MySQL columns.
+	generated bool   // generated (computed) column.
+}
+
+// column returns the column with the given name, or nil.
+func (t *ddlTable) column(name string) *ddlColumn {
+	for _, c := range t.columns {
+		if strings.EqualFold(c.name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// primaryKey returns the columns of the primary key of the table.
+func (t *ddlTable) primaryKey() []string {
+	for _, idx := range t.indexes {
+		if idx.Primary {
+			return idx.Columns
+		}
+	}
+	return nil
+}
+
+// addIndex adds an index of the table. Unnamed indexes are named like the
+// database would name them.
+func (t *ddlTable) addIndex(dialect, name string, unique, primary bool, columns []string) {
+	if len(columns) == 0 {
+		return
+	}
+	switch {
+	case primary && dialect == leopards.MySQL:
+		name = `PRIMARY`
+	case primary && name == ``:
+		name = t.name + `_pkey`
+	case name == `` && dialect == leopards.MySQL:
+		name = columns[0]
+		for i := 2; t.index(name) != nil; i++ {
+			name = columns[0] + `_` + strconv.Itoa(i)
+		}
+	case name == `` && unique:
+		name = t.name + `_` + strings.Join(columns, `_`) + `_key`
+	case name == ``:
+		name = t.name + `_` + strings.Join(columns, `_`) + `_idx`
+	}
+	if idx := t.index(name); idx != nil {
+		idx.Columns = columns
+		return
+	}
+	t.indexes = append(t.indexes, &IndexInfo{Name: name, Unique: unique || primary, Primary: primary, Columns: columns})
+}
+
+// index returns the index with the given name, or nil.
+func (t *ddlTable) index(name string) *IndexInfo {
+	for _, idx := range t.indexes {
+		if idx.Name == name {
+			return idx
+		}
+	}
+	return nil
+}
+
+// addForeignKey adds a foreign key of the table. Unnamed foreign keys are
+// named like the database would name them.
+func (t *ddlTable) addForeignKey(dialect string, fk *ForeignKeyInfo) {
+	if fk.Name == `` {
+		switch dialect {
+		case leopards.MySQL:
+			fk.Name = t.name + `_ibfk_` + strconv.Itoa(len(t.foreignKeys)+1)
+		default:
+			fk.Name = t.name + `_` + strings.Join(fk.Columns, `_`) + `_fkey`
+		}
+	}
+	if fk.OnUpdate == `` {
+		fk.OnUpdate = `NO ACTION`
+	}
+	if fk.OnDelete == `` {
+		fk.OnDelete = `NO ACTION`
+	}
+	t.foreignKeys = append(t.foreignKeys, fk)
+}
+
+// ddlParser parses the CREATE TABLE statements of DDL files, and the
+// statements changing the tables that database dumps hold: ALTER TABLE,
+// CREATE INDEX and COMMENT ON. Other statements are skipped.
+type ddlParser struct {
+	dialect  string
+	database string // database of the USE statement, or of the qualified table names.
+	tables   []*ddlTable
+	enums    map[string][]string // labels of the PostgreSQL enum types by schema.name.
+	domains  map[string]string   // normalized types of the PostgreSQL domains.
+	file     string
+	tokens   []ddlToken
+	src      string
+	pos      int
+}
+
+// parse parses the DDL source of the file.
+func (p *ddlParser) parse(file, src string) error {
+	tokens, err := lexDDL(src, p.dialect)
+	if err != nil {
+		return fmt.Errorf(`%s:%w`, file, err)
+	}
+	p.file, p.src, p.tokens, p.pos = file, src, tokens, 0
+	for p.pos < len(p.tokens) {
+		if err := p.statement(); err != nil {
+			return err
+		}
+		for p.pos < len(p.tokens) && !p.punct(`;`) {
+			p.pos++
+		}
+		p.pos++
+	}
+	return nil
+}
+
+// errorf returns an error at the line of the current token.
+func (p *ddlParser) errorf(format string, args ...any) error {
+	line := 0
+	switch {
+	case p.pos < len(p.tokens):
+		line = p.tokens[p.pos].line
+	case len(p.tokens) > 0:
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return fmt.Errorf(`%s:%d: %s`, p.file, line, fmt.Sprintf(format, args...))
+}
+
+// keyword reports if the next tokens are the given keywords.
+func (p *ddlParser) keyword(keywords ...string) bool {
+	for i, kw := range keywords {
+		if p.pos+i >= len(p.tokens) {
+			return false
+		}
+		t := p.tokens[p.pos+i]
+		if t.kind != tokIdent || !strings.EqualFold(t.text, kw) {
+			return false
+		}
+	}
+	return true
+}
+
+// accept consumes the given keywords if they are next.
+func (p *ddlParser) accept(keywords ...string) bool {
+	if !p.keyword(keywords...) {
+		return false
+	}
+	p.pos += len(keywords)
+	return true
+}
+
+// punct reports if the next token is the given punctuation.
+func (p *ddlParser) punct(s string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokPunct && p.tokens[p.pos].text == s
+}
+
+// end reports if the current element of a list ends, at a comma, a closing
+// parenthesis or the end of the statement.
+func (p *ddlParser) end() bool {
+	return p.pos >= len(p.tokens) || p.punct(`,`) || p.punct(`)`) || p.punct(`;`)
+}
+
+// skip consumes the next token, or the whole parenthesized group.
+func (p *ddlParser) skip() {
+	if !p.punct(`(`) && !p.punct(`[`) {
+		p.pos++
+		return
+	}
+	for depth := 0; p.pos < len(p.tokens) && !p.punct(`;`); p.pos++ {
+		switch {
+		case p.punct(`(`), p.punct(`[`):
+			depth++
+		case p.punct(`)`), p.punct(`]`):
+			depth--
+		}
+		if depth == 0 {
+			p.pos++
+			return
+		}
+	}
+}
+
+// skipElement consumes the rest of the current element of a list.
+func (p *ddlParser) skipElement() {
+	for !p.end() {
+		p.skip()
+	}
+}
+
+// name consumes an identifier. Unquoted PostgreSQL identifiers are folded to lower case.
+func (p *ddlParser) name() (string, error) {
+	if p.pos >= len(p.tokens) {
+		return ``, p.errorf(`expect name`)
+	}
+	t := p.tokens[p.pos]
+	switch {
+	case t.kind == tokQuoted:
+	case t.kind == tokIdent && p.dialect == leopards.Postgres:
+		t.text = strings.ToLower(t.text)
+	case t.kind == tokIdent:
+	default:
+		return ``, p.errorf(`expect name, got %q`, t.text)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// tableName consumes a table name, qualified with its schema or database.
+func (p *ddlParser) tableName() (string, string, error) {
+	name, err := p.name()
+	if err != nil {
+		return ``, ``, err
+	}
+	if !p.punct(`.`) {
+		return ``, name, nil
+	}
+	p.pos++
+	table, err := p.name()
+	return name, table, err
+}
+
+// table returns the table with the given name, or nil.
+func (p *ddlParser) table(schema, name string) *ddlTable {
+	for _, t := range p.tables {
+		if t.name == name && (schema == `` || t.schema == schema) {
+			return t
+		}
+	}
+	return nil
+}
+
+// str consumes a string literal.
+func (p *ddlParser) str() (string, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokString {
+		return ``, p.errorf(`expect string`)
+	}
+	p.pos++
+	return p.tokens[p.pos-1].text, nil
+}
+
+// statement parses a statement.
+func (p *ddlParser) statement() error {
+	switch {
+	case p.accept(`USE`):
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		p.database = name
+	case p.accept(`CREATE`):
+		p.accept(`OR`, `REPLACE`)
+		for p.accept(`TEMPORARY`) || p.accept(`TEMP`) || p.accept(`UNLOGGED`) || p.accept(`GLOBAL`) || p.accept(`LOCAL`) {
+		}
+		switch {
+		case p.accept(`TABLE`):
+			return p.createTable()
+		case p.accept(`TYPE`):
+			return p.createType()
+		case p.accept(`DOMAIN`):
+			return p.createDomain()
+		case p.accept(`UNIQUE`, `INDEX`):
+			return p.createIndex(true)
+		case p.accept(`INDEX`), p.accept(`FULLTEXT`, `INDEX`), p.accept(`SPATIAL`, `INDEX`):
+			return p.createIndex(false)
+		}
+	case p.accept(`ALTER`, `TABLE`):
+		return p.alterTable()
+	case p.accept(`COMMENT`, `ON`):
+		return p.commentOn()
+	}
+	return nil
+}
+
+// createTable parses the CREATE TABLE statement after the TABLE keyword.
+func (p *ddlParser) createTable() error {
+	p.accept(`IF`, `NOT`, `EXISTS`)
+	schema, name, err := p.tableName()
+	if err != nil {
+		return err
+	}
+	if !p.punct(`(`) {
+		// CREATE TABLE ... LIKE, AS SELECT and PARTITION OF.
+		return nil
+	}
+	if schema == `` {
+		schema = p.database
+		if p.dialect == leopards.Postgres {
+			schema = `public`
+		}
+	} else if p.dialect == leopards.MySQL && p.database == `` {
+		p.database = schema
+	}
+	t := &ddlTable{schema: schema, name: name}
+	if old := p.table(schema, name); old != nil {
+		*old = *t
+		t = old
+	} else {
+		p.tables = append(p.tables, t)
+	}
+	p.pos++
+	for !p.punct(`)`) {
+		if err := p.element(t); err != nil {
+			return err
+		}
+		if !p.end() {
+			return p.errorf(`unexpected %q in table %s`, p.tokens[p.pos].text, name)
+		}
+		if p.pos >= len(p.tokens) || p.punct(`;`) {
+			return p.errorf(`unterminated table %s`, name)
+		}
+		if p.punct(`,`) {
+			p.pos++
+		}
+	}
+	p.pos++
+	// Table options: ENGINE=InnoDB ... COMMENT='...'.
+	for p.pos < len(p.tokens) && !p.punct(`;`) {
+		if p.accept(`COMMENT`) {
+			if p.punct(`=`) {
+				p.pos++
+			}
+			if t.comment, err = p.str(); err != nil {
+				return err
+			}
+			continue
+		}
+		p.skip()
+	}
+	return nil
+}
+
+// element parses a column or a constraint of the table.
+func (p *ddlParser) element(t *ddlTable) error {
+	constraint := ``
+	if p.accept(`CONSTRAINT`) {
+		if !p.keyword(`PRIMARY`) && !p.keyword(`UNIQUE`) && !p.keyword(`FOREIGN`) && !p.keyword(`CHECK`) {
+			name, err := p.name()
+			if err != nil {
+				return err
+			}
+			constraint = name
+		}
+	}
+	switch {
+	case p.accept(`PRIMARY`, `KEY`):
+		columns, err := p.indexColumns()
+		if err != nil {
+			return err
+		}
+		t.addIndex(p.dialect, constraint, true, true, columns)
+	case p.accept(`UNIQUE`), p.dialect == leopards.MySQL && (p.accept(`KEY`) || p.accept(`INDEX`) || p.accept(`FULLTEXT`) || p.accept(`SPATIAL`)):
+		unique := strings.EqualFold(p.tokens[p.pos-1].text, `UNIQUE`)
+		if !p.accept(`KEY`) {
+			p.accept(`INDEX`)
+		}
+		name := constraint
+		if !p.punct(`(`) && !p.keyword(`USING`) {
+			n, err := p.name()
+			if err != nil {
+				return err
+			}
+			name = n
+		}
+		columns, err := p.indexColumns()
+		if err != nil {
+			return err
+		}
+		t.addIndex(p.dialect, name, unique, false, columns)
+	case p.accept(`FOREIGN`, `KEY`):
+		if !p.punct(`(`) {
+			name, err := p.name()
+			if err != nil {
+				return err
+			}
+			if constraint == `` {
+				constraint = name
+			}
+		}
+		columns, err := p.indexColumns()
+		if err != nil {
+			return err
+		}
+		if !p.accept(`REFERENCES`) {
+			return p.errorf(`expect REFERENCES in foreign key of table %s`, t.name)
+		}
+		fk, err := p.references(columns)
+		if err != nil {
+			return err
+		}
+		fk.Name = constraint
+		t.addForeignKey(p.dialect, fk)
+	case constraint != ``, p.keyword(`CHECK`), p.keyword(`LIKE`), p.keyword(`EXCLUDE`, `USING`):
+		// CHECK and EXCLUDE constraints.
+	default:
+		c, err := p.column(t, constraint)
+		if err != nil {
+			return err
+		}
+		if old := t.column(c.name); old != nil {
+			*old = *c
+		} else {
+			t.columns = append(t.columns, c)
+		}
+	}
+	p.skipElement()
+	return nil
+}
+
+// indexColumns parses the parenthesized column list of an index or a key. The
+// prefix lengths and the orders of the columns are skipped. Functional indexes
+// have no columns.
+func (p *ddlParser) indexColumns() ([]string, error) {
+	for p.accept(`USING`) {
+		p.skip()
+	}
+	if !p.punct(`(`) {
+		return nil, p.errorf(`expect column list`)
+	}
+	p.pos++
+	var (
+		columns []string
+		expr    bool
+	)
+	for !p.punct(`)`) {
+		if p.pos >= len(p.tokens) || p.punct(`;`) {
+			return nil, p.errorf(`unterminated column list`)
+		}
+		if p.punct(`(`) {
+			expr = true
+		} else {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, name)
+			// Prefix lengths, e.g. name(10), or function calls.
+			if p.punct(`(`) && (p.pos+1 >= len(p.tokens) || p.tokens[p.pos+1].kind != tokNumber) {
+				expr = true
+			}
+		}
+		p.skipElement()
+		if p.punct(`,`) {
+			p.pos++
+		}
+	}
+	p.pos++
+	if expr {
+		return nil, nil
+	}
+	return columns, nil
+}
+
+// references parses the REFERENCES clause of a foreign key after the keyword.
+func (p *ddlParser) references(columns []string) (*ForeignKeyInfo, error) {
+	_, table, err := p.tableName()
+	if err != nil {
+		return nil, err
+	}
+	fk := &ForeignKeyInfo{Columns: columns, RefTable: table}
+	if p.punct(`(`) {
+		if fk.RefColumns, err = p.indexColumns(); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		switch {
+		case p.accept(`MATCH`):
+			p.skip()
+		case p.accept(`ON`, `DELETE`):
+			fk.OnDelete = p.action()
+		case p.accept(`ON`, `UPDATE`):
+			fk.OnUpdate = p.action()
+		default:
+			return fk, nil
+		}
+	}
+}
+
+// action parses a referential action.
+func (p *ddlParser) action() string {
+	for _, action := range [][]string{{`CASCADE`}, {`RESTRICT`}, {`NO`, `ACTION`}, {`SET`, `NULL`}, {`SET`, `DEFAULT`}} {
+		if p.accept(action...) {
+			return strings.Join(action, ` `)
+		}
+	}
+	return ``
+}
+
+// column parses the definition of a column, and adds the keys defined with
+// the column to the table.
+func (p *ddlParser) column(t *ddlTable, constraint string) (*ddlColumn, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	c := &ddlColumn{name: name, typ: p.columnType()}
+	if c.typ == `` {
+		return nil, p.errorf(`expect type of column %s.%s`, t.name, name)
+	}
+	for !p.end() {
+		switch {
+		case p.accept(`CONSTRAINT`):
+			if constraint, err = p.name(); err != nil {
+				return nil, err
+			}
+			continue
+		case p.accept(`NOT`, `NULL`):
+			c.notNull = true
+		case p.accept(`NULL`):
+		case p.accept(`DEFAULT`):
+			start := p.pos
+			def := p.expr()
+			c.def, c.literal = &def, nil
+			if p.pos == start+1 && p.tokens[start].kind == tokString {
+				c.literal = &p.tokens[start].text
+			}
+		case p.accept(`AUTO_INCREMENT`), p.accept(`AUTOINCREMENT`):
+			c.auto = true
+		case p.accept(`PRIMARY`, `KEY`), p.dialect == leopards.MySQL && p.accept(`KEY`):
+			t.addIndex(p.dialect, constraint, true, true, []string{c.name})
+		case p.accept(`UNIQUE`):
+			p.accept(`KEY`)
+			t.addIndex(p.dialect, constraint, true, false, []string{c.name})
+		case p.accept(`COMMENT`):
+			if c.comment, err = p.str(); err != nil {
+				return nil, err
+			}
+		case p.accept(`REFERENCES`):
+			fk, err := p.references([]string{c.name})
+			if err != nil {
+				return nil, err
+			}
+			fk.Name = constraint
+			t.addForeignKey(p.dialect, fk)
+		case p.accept(`ON`, `UPDATE`):
+			c.onUpdate = p.expr()
+		case p.accept(`GENERATED`):
+			if !p.accept(`ALWAYS`) {
+				p.accept(`BY`, `DEFAULT`)
+			}
+			p.accept(`AS`)
+			if p.accept(`IDENTITY`) {
+				c.auto = true
+			} else {
+				c.generated = true
+			}
+			if p.punct(`(`) {
+				p.skip()
+			}
+		case p.accept(`AS`):
+			c.generated = true
+			p.skip()
+		default:
+			p.skip()
+		}
+		constraint = ``
+	}
+	return c, nil
+}
+
+// typeWords are the words continuing the column types, e.g. double precision.
+var typeWords = map[string]struct{}{
+	`unsigned`: {}, `signed`: {}, `zerofill`: {}, `varying`: {}, `precision`: {},
+	`with`: {}, `without`: {}, `time`: {}, `zone`: {}, `array`: {},
+}
+
+// columnType parses a column type and returns it normalized: lower-cased, with
+// its words separated by a single space and without spaces around the
+// parentheses and the commas.
+func (p *ddlParser) columnType() string {
+	start := p.pos
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokIdent && p.tokens[p.pos].kind != tokQuoted {
+		return ``
+	}
+	p.pos++
+	for p.punct(`.`) {
+		p.pos += 2
+	}
+	for p.pos < len(p.tokens) {
+		t := p.tokens[p.pos]
+		_, word := typeWords[strings.ToLower(t.text)]
+		switch {
+		case p.punct(`(`), p.punct(`[`):
+			p.skip()
+		case t.kind == tokIdent && word:
+			p.pos++
+		default:
+			return normalizeType(p.tokens[start:p.pos])
+		}
+	}
+	return normalizeType(p.tokens[start:p.pos])
+}
+
+// normalizeType returns the normalized text of the tokens of a column type.
+func normalizeType(tokens []ddlToken) string {
+	var b strings.Builder
+	for i, t := range tokens {
+		text := t.text
+		switch t.kind {
+		case tokIdent:
+			text = strings.ToLower(text)
+		case tokString:
+			text = `'` + strings.ReplaceAll(text, `'`, `''`) + `'`
+		}
+		if i > 0 && (t.kind == tokIdent || t.kind == tokQuoted) && (tokens[i-1].kind != tokPunct || tokens[i-1].text == `)` || tokens[i-1].text == `]`) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// exprStops are the keywords ending the default value expressions of the columns.
+var exprStops = map[string]struct{}{
+	`not`: {}, `null`: {}, `primary`: {}, `unique`: {}, `key`: {}, `comment`: {}, `auto_increment`: {},
+	`autoincrement`: {}, `references`: {}, `check`: {}, `constraint`: {}, `collate`: {}, `generated`: {},
+	`on`: {}, `character`: {}, `visible`: {}, `invisible`: {}, `storage`: {}, `column_format`: {},
+}
+
+// expr parses an expression, and returns its source text.
+func (p *ddlParser) expr() string {
+	start := p.pos
+	p.skip()
+	for !p.end() {
+		if p.punct(`::`) {
+			// The cast type, e.g. 'a'::character varying.
+			p.pos++
+			p.skip()
+			continue
+		}
+		if _, ok := exprStops[strings.ToLower(p.tokens[p.pos].text)]; ok && p.tokens[p.pos].kind == tokIdent {
+			break
+		}
+		p.skip()
+	}
+	if start >= len(p.tokens) || start == p.pos {
+		return ``
+	}
+	return p.src[p.tokens[start].start:p.tokens[p.pos-1].end]
+}
+
+// createType parses the CREATE TYPE statement of the enum types after the
+// TYPE keyword. The other types are skipped.
+func (p *ddlParser) createType() error {
+	schema, name, err := p.tableName()
+	if err != nil {
+		return err
+	}
+	if schema == `` {
+		schema = `public`
+	}
+	if !p.accept(`AS`, `ENUM`) || !p.punct(`(`) {
+		return nil
+	}
+	p.pos++
+	var labels []string
+	for !p.punct(`)`) {
+		label, err := p.str()
+		if err != nil {
+			return err
+		}
+		labels = append(labels, label)
+		if p.punct(`,`) {
+			p.pos++
+		}
+	}
+	if p.enums == nil {
+		p.enums = make(map[string][]string)
+	}
+	p.enums[schema+`.`+name] = labels
+	return nil
+}
+
+// createDomain parses the CREATE DOMAIN statement after the DOMAIN keyword.
+func (p *ddlParser) createDomain() error {
+	_, name, err := p.tableName()
+	if err != nil {
+		return err
+	}
+	p.accept(`AS`)
+	typ := p.columnType()
+	if typ == `` {
+		return p.errorf(`expect type of domain %s`, name)
+	}
+	if p.domains == nil {
+		p.domains = make(map[string]string)
+	}
+	p.domains[name] = typ
+	return nil
+}
+
+// createIndex parses the CREATE INDEX statement after the INDEX keyword.
+func (p *ddlParser) createIndex(unique bool) error {
+	p.accept(`CONCURRENTLY`)
+	p.accept(`IF`, `NOT`, `EXISTS`)
+	name := ``
+	if !p.keyword(`ON`) {
+		n, err := p.name()
+		if err != nil {
+			return err
+		}
+		name = n
+	}
+	if !p.accept(`ON`) {
+		return p.errorf(`expect ON in index %s`, name)
+	}
+	p.accept(`ONLY`)
+	schema, table, err := p.tableName()
+	if err != nil {
+		return err
+	}
+	columns, err := p.indexColumns()
+	if err != nil {
+		return err
+	}
+	if t := p.table(schema, table); t != nil {
+		t.addIndex(p.dialect, name, unique, false, columns)
+	}
+	return nil
+}
+
+// alterTable parses the ALTER TABLE statement after the TABLE keyword. It
+// applies the added columns and constraints, the modified columns, and the
+// defaults, the NOT NULL constraints and the identities set on the columns.
+func (p *ddlParser) alterTable() error {
+	p.accept(`IF`, `EXISTS`)
+	p.accept(`ONLY`)
+	schema, name, err := p.tableName()
+	if err != nil {
+		return err
+	}
+	t := p.table(schema, name)
+	if t == nil {
+		return nil
+	}
+	for p.pos < len(p.tokens) && !p.punct(`;`) {
+		switch {
+		case p.accept(`ADD`):
+			p.accept(`COLUMN`)
+			p.accept(`IF`, `NOT`, `EXISTS`)
+			if err := p.element(t); err != nil {
+				return err
+			}
+		case p.accept(`MODIFY`):
+			p.accept(`COLUMN`)
+			if err := p.element(t); err != nil {
+				return err
+			}
+		case p.accept(`CHANGE`):
+			p.accept(`COLUMN`)
+			old, err := p.name()
+			if err != nil {
+				return err
+			}
+			if c := t.column(old); c != nil {
+				c.name = p.tokens[p.pos].text
+			}
+			if err := p.element(t); err != nil {
+				return err
+			}
+		case p.accept(`ALTER`):
+			p.accept(`COLUMN`)
+			column, err := p.name()
+			if err != nil {
+				return err
+			}
+			c := t.column(column)
+			if c == nil {
+				p.skipElement()
+				break
+			}
+			switch {
+			case p.accept(`SET`, `DEFAULT`):
+				def := p.expr()
+				c.def = &def
+				c.auto = c.auto || strings.HasPrefix(def, `nextval(`)
+			case p.accept(`DROP`, `DEFAULT`):
+				c.def = nil
+			case p.accept(`SET`, `NOT`, `NULL`):
+				c.notNull = true
+			case p.accept(`DROP`, `NOT`, `NULL`):
+				c.notNull = false
+			case p.accept(`ADD`, `GENERATED`):
+				c.auto = true
+			}
+			p.skipElement()
+		default:
+			p.skipElement()
+		}
+		if p.punct(`,`) {
+			p.pos++
+		}
+	}
+	return nil
+}
+
+// commentOn parses the COMMENT ON statement after the ON keyword.
+func (p *ddlParser) commentOn() error {
+	switch {
+	case p.accept(`TABLE`):
+		schema, name, err := p.tableName()
+		if err != nil {
+			return err
+		}
+		if !p.accept(`IS`) {
+			return nil
+		}
+		comment, err := p.str()
+		if t := p.table(schema, name); t != nil && err == nil {
+			t.comment = comment
+		}
+	case p.accept(`COLUMN`):
+		var names []string
+		for {
+			name, err := p.name()
+			if err != nil {
+				return err
+			}
+			names = append(names, name)
+			if !p.punct(`.`) {
+				break
+			}
+			p.pos++
+		}
+		if len(names) < 2 || !p.accept(`IS`) {
+			return nil
+		}
+		schema, table, column := ``, names[len(names)-2], names[len(names)-1]
+		if len(names) > 2 {
+			schema = names[len(names)-3]
+		}
+		comment, err := p.str()
+		if t := p.table(schema, table); t != nil && err == nil {
+			if c := t.column(column); c != nil {
+				c.comment = comment
+			}
+		}
+	}
+	return nil
+}
+
+// resolve sets the referenced columns of the foreign keys referencing the
+// primary keys implicitly, and the NOT NULL constraints of the primary key
+// columns.
+func (p *ddlParser) resolve() {
+	for _, t := range p.tables {
+		for _, name := range t.primaryKey() {
+			if c := t.column(name); c != nil {
+				c.notNull = true
+			}
+		}
+		for _, fk := range t.foreignKeys {
+			if len(fk.RefColumns) == 0 {
+				if ref := p.table(``, fk.RefTable); ref != nil {
+					fk.RefColumns = ref.primaryKey()
+				}
+			}
+		}
+	}
+}
+
+// typeArgs returns the data type of a normalized column type, and the
+// numbers of its parenthesized arguments: decimal(10,2) => decimal, [10 2].
+func typeArgs(typ string) (string, []int64) {
+	i := strings.IndexByte(typ, '(')
+	if i == -1 {
+		return typ, nil
+	}
+	j := strings.IndexByte(typ[i:], ')')
+	if j == -1 {
+		return typ, nil
+	}
+	var args []int64
+	for _, s := range strings.Split(typ[i+1:i+j], `,`) {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			args = append(args, n)
+		}
+	}
+	return strings.TrimSpace(typ[:i] + typ[i+j+1:]), args
+}
+
+// mysqlAliases are the MySQL data types stored as other types.
+var mysqlAliases = map[string]string{
+	`bool`:    `tinyint(1)`,
+	`boolean`: `tinyint(1)`,
+	`integer`: `int`,
+	`dec`:     `decimal`,
+	`fixed`:   `decimal`,
+	`numeric`: `decimal`,
+}
+
+// mysqlTables converts the tables to the tables introspected from MySQL.
+func (p *ddlParser) mysqlTables() []Table {
+	tables := make([]Table, 0, len(p.tables))
+	for _, t := range p.tables {
+		table := Table{TableName: t.name, Comment: t.comment}
+		for _, c := range t.columns {
+			columnType := c.typ
+			dataType := strings.Fields(columnType)[0]
+			if i := strings.IndexByte(dataType, '('); i != -1 {
+				dataType = dataType[:i]
+			}
+			if alias, ok := mysqlAliases[dataType]; ok {
+				columnType = alias + strings.TrimPrefix(columnType, dataType)
+				dataType, _ = typeArgs(alias)
+			}
+			name := c.name
+			column := Column{
+				TableSchema:   t.schema,
+				TableName:     t.name,
+				ColumnName:    &name,
+				ColumnDefault: c.def,
+				IsNullable:    `YES`,
+				DataType:      &dataType,
+				ColumnType:    columnType,
+				ColumnComment: c.comment,
+			}
+			if c.notNull {
+				column.IsNullable = `NO`
+			}
+			if c.literal != nil {
+				// information_schema reports the literal values unquoted.
+				column.ColumnDefault = c.literal
+			}
+			var extra []string
+			switch {
+			case c.auto:
+				extra = append(extra, `auto_increment`)
+			case c.generated:
+				extra = append(extra, `VIRTUAL GENERATED`)
+			case c.def != nil && strings.HasPrefix(*c.def, `(`):
+				extra = append(extra, `DEFAULT_GENERATED`)
+			}
+			if c.onUpdate != `` {
+				extra = append(extra, `on update `+c.onUpdate)
+			}
+			if len(extra) > 0 {
+				e := strings.Join(extra, ` `)
+				column.Extra = &e
+			}
+			if _, args := typeArgs(columnType); len(args) > 0 {
+				switch dataType {
+				case `char`, `varchar`, `binary`, `varbinary`:
+					column.CharacterMaximumLength = &args[0]
+				}
+			}
+			for _, idx := range t.indexes {
+				switch {
+				case idx.Primary && contains(idx.Columns, c.name):
+					column.ColumnKey = `PRI`
+				case column.ColumnKey == `` && idx.Unique && len(idx.Columns) == 1 && idx.Columns[0] == c.name:
+					column.ColumnKey = `UNI`
+				}
+			}
+			table.Columns = append(table.Columns, column)
+		}
+		for _, idx := range t.indexes {
+			nonUnique := 1
+			if idx.Unique {
+				nonUnique = 0
+			}
+			for i := range idx.Columns {
+				table.Indexes = append(table.Indexes, Index{IndexName: idx.Name, NonUnique: nonUnique, SeqInIndex: i + 1, ColumnName: &idx.Columns[i]})
+			}
+		}
+		for _, fk := range t.foreignKeys {
+			for i := range fk.Columns {
+				if i >= len(fk.RefColumns) {
+					break
+				}
+				table.ForeignKeys = append(table.ForeignKeys, KeyColumn{
+					ConstraintName:       fk.Name,
+					ColumnName:           fk.Columns[i],
+					ReferencedTableName:  fk.RefTable,
+					ReferencedColumnName: fk.RefColumns[i],
+					UpdateRule:           fk.OnUpdate,
+					DeleteRule:           fk.OnDelete,
+				})
+			}
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// pgTypes are the udt names and the data types of the PostgreSQL column types
+// and their aliases.
+var pgTypes = map[string][2]string{
+	`smallint`:                    {`int2`, `smallint`},
+	`int2`:                        {`int2`, `smallint`},
+	`smallserial`:                 {`int2`, `smallint`},
+	`serial2`:                     {`int2`, `smallint`},
+	`integer`:                     {`int4`, `integer`},
+	`int`:                         {`int4`, `integer`},
+	`int4`:                        {`int4`, `integer`},
+	`serial`:                      {`int4`, `integer`},
+	`serial4`:                     {`int4`, `integer`},
+	`bigint`:                      {`int8`, `bigint`},
+	`int8`:                        {`int8`, `bigint`},
+	`bigserial`:                   {`int8`, `bigint`},
+	`serial8`:                     {`int8`, `bigint`},
+	`boolean`:                     {`bool`, `boolean`},
+	`bool`:                        {`bool`, `boolean`},
+	`real`:                        {`float4`, `real`},
+	`float4`:                      {`float4`, `real`},
+	`double precision`:            {`float8`, `double precision`},
+	`float8`:                      {`float8`, `double precision`},
+	`float`:                       {`float8`, `double precision`},
+	`numeric`:                     {`numeric`, `numeric`},
+	`decimal`:                     {`numeric`, `numeric`},
+	`character varying`:           {`varchar`, `character varying`},
+	`varchar`:                     {`varchar`, `character varying`},
+	`character`:                   {`bpchar`, `character`},
+	`char`:                        {`bpchar`, `character`},
+	`bpchar`:                      {`bpchar`, `character`},
+	`bit varying`:                 {`varbit`, `bit varying`},
+	`varbit`:                      {`varbit`, `bit varying`},
+	`bit`:                         {`bit`, `bit`},
+	`time`:                        {`time`, `time without time zone`},
+	`time without time zone`:      {`time`, `time without time zone`},
+	`timetz`:                      {`timetz`, `time with time zone`},
+	`time with time zone`:         {`timetz`, `time with time zone`},
+	`timestamp`:                   {`timestamp`, `timestamp without time zone`},
+	`timestamp without time zone`: {`timestamp`, `timestamp without time zone`},
+	`timestamptz`:                 {`timestamptz`, `timestamp with time zone`},
+	`timestamp with time zone`:    {`timestamptz`, `timestamp with time zone`},
+}
+
+// splitPgType splits a normalized PostgreSQL column type into its schema,
+// name, arguments, and whether it is an array type.
+func splitPgType(typ string) (schema, name string, args []int64, array bool) {
+	name, args = typeArgs(typ)
+	array = strings.HasSuffix(name, `]`) || strings.HasSuffix(name, ` array`)
+	name = strings.TrimSuffix(name, ` array`)
+	if i := strings.IndexByte(name, '['); i != -1 {
+		name = name[:i]
+	}
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndexByte(name, '.'); i != -1 {
+		schema, name = name[:i], name[i+1:]
+	}
+	return schema, name, args, array
+}
+
+// pgColumnType returns the udt name, the data type and the maximum length of
+// a normalized PostgreSQL column type. Types without an entry in pgTypes are
+// named by themselves and their data type is USER-DEFINED, except for the
+// builtin types, e.g. text or jsonb.
+func pgColumnType(typ string) (string, string, *int) {
+	_, base, args, array := splitPgType(typ)
+	udt, dataType := base, `USER-DEFINED`
+	if t, ok := pgTypes[base]; ok {
+		udt, dataType = t[0], t[1]
+	} else if _, ok := pgType(base); ok {
+		dataType = base
+	}
+	if array {
+		return `_` + udt, `ARRAY`, nil
+	}
+	switch udt {
+	case `varchar`, `bpchar`, `bit`, `varbit`:
+		if len(args) > 0 {
+			size := int(args[0])
+			return udt, dataType, &size
+		}
+	}
+	return udt, dataType, nil
+}
+
+// pgFormatType returns the column type of a normalized PostgreSQL column type
+// as written by format_type, e.g. character varying(64), timestamp(3) with
+// time zone or text[]. Types of other schemas than public are qualified.
+func pgFormatType(typ string) string {
+	schema, name, args, array := splitPgType(typ)
+	if t, ok := pgTypes[name]; ok && schema == `` {
+		name = t[1]
+	} else if schema != `` && schema != `public` {
+		name = schema + `.` + name
+	}
+	var params string
+	switch {
+	case len(args) > 0:
+		s := make([]string, len(args))
+		for i, arg := range args {
+			s[i] = strconv.FormatInt(arg, 10)
+		}
+		params = `(` + strings.Join(s, `,`) + `)`
+	case name == `character` || name == `bit`:
+		params = `(1)`
+	}
+	if i := strings.IndexByte(name, ' '); i != -1 && strings.HasSuffix(name, ` time zone`) {
+		name = name[:i] + params + name[i:]
+	} else {
+		name += params
+	}
+	if array {
+		name += `[]`
+	}
+	return name
+}
+
+// pgTables converts the tables to the tables introspected from PostgreSQL.
+func (p *ddlParser) pgTables() []PgTable {
+	tables := make([]PgTable, 0, len(p.tables))
+	for _, t := range p.tables {
+		table := PgTable{TableSchema: t.schema, TableName: t.name, TableType: `BASE TABLE`}
+		if t.comment != `` {
+			comment := t.comment
+			table.Comment = &comment
+		}
+		for _, c := range t.columns {
+			typ, domain := c.typ, ``
+			if _, name, _, array := splitPgType(c.typ); !array {
+				if base, ok := p.domains[name]; ok {
+					typ, domain = base, name
+				}
+			}
+			udt, dataType, size := pgColumnType(typ)
+			udtSchema, _, _, _ := splitPgType(typ)
+			if udtSchema == `` {
+				udtSchema = `public`
+			}
+			name := c.name
+			column := PgColumn{
+				TableSchema:            t.schema,
+				TableName:              t.name,
+				ColumnName:             &name,
+				ColumnDefault:          c.def,
+				IsNullAble:             `YES`,
+				DataType:               dataType,
+				ColumnType:             pgFormatType(c.typ),
+				UdtName:                udt,
+				UdtSchema:              udtSchema,
+				CharacterMaximumLength: size,
+				IsIdentity:             `NO`,
+				Enum:                   p.enums[udtSchema+`.`+strings.TrimPrefix(udt, `_`)],
+			}
+			if domain != `` {
+				column.DomainName = &domain
+			}
+			base, _ := typeArgs(c.typ)
+			switch base {
+			case `serial`, `serial2`, `serial4`, `serial8`, `smallserial`, `bigserial`:
+				def := fmt.Sprintf(`nextval('%s_%s_seq'::regclass)`, t.name, c.name)
+				column.ColumnDefault = &def
+				c.notNull = true
+			default:
+				if c.auto && (c.def == nil || !strings.HasPrefix(*c.def, `nextval(`)) {
+					column.IsIdentity = `YES`
+				}
+			}
+			if c.notNull {
+				column.IsNullAble = `NO`
+			}
+			if c.comment != `` {
+				comment := c.comment
+				column.Comment = &comment
+			}
+			table.Columns = append(table.Columns, column)
+		}
+		for _, idx := range t.indexes {
+			for _, column := range idx.Columns {
+				table.Indexes = append(table.Indexes, PgIndex{IndexName: idx.Name, IsUnique: idx.Unique, IsPrimary: idx.Primary, ColumnName: column})
+			}
+		}
+		for _, fk := range t.foreignKeys {
+			for i := range fk.Columns {
+				if i >= len(fk.RefColumns) {
+					break
+				}
+				table.ForeignKeys = append(table.ForeignKeys, PgForeignKey{
+					ConstraintName: fk.Name,
+					ColumnName:     fk.Columns[i],
+					RefTable:       fk.RefTable,
+					RefColumn:      fk.RefColumns[i],
+					UpdateRule:     pgRuleCode(fk.OnUpdate),
+					DeleteRule:     pgRuleCode(fk.OnDelete),
+				})
+			}
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// pgRuleCode returns the pg_constraint action code of a referential action.
+func pgRuleCode(rule string) string {
+	for code, r := range pgRules {
+		if r == rule {
+			return code
+		}
+	}
+	return `a`
+}
+
+// ddlGenerate generates the code of the tables created by the DDL files.
+func ddlGenerate(cmd *cobra.Command, args []string) error {
+	dialect, _ := cmd.Flags().GetString(`dialect`)
+	switch dialect {
+	case leopards.MySQL, leopards.Postgres:
+	default:
+		return fmt.Errorf(`unknown dialect %q, expect mysql or postgres`, dialect)
+	}
+	p := &ddlParser{dialect: dialect}
+	for _, path := range args {
+		var (
+			b   []byte
+			err error
+		)
+		if path == `-` {
+			b, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			b, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.parse(path, string(b)); err != nil {
+			return err
+		}
+	}
+	p.resolve()
+	if len(p.tables) == 0 {
+		return fmt.Errorf(`no CREATE TABLE statement in %s`, strings.Join(args, `, `))
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if dialect == leopards.Postgres {
+		return render(cmd, pgSchema(cfg, p.database, p.pgTables()))
+	}
+	return render(cmd, mysqlSchema(cfg, p.database, p.mysqlTables()))
+}
+
+var ddlCMD = &cobra.Command{
+	Use:   `ddl file... [-h]`,
+	Short: `Generate from the CREATE TABLE statements of DDL files, without a database`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+		return ddlGenerate(cmd, args)
+	},
+}
+
+func init() {
+	ddlCMD.Flags().StringP(`dialect`, `d`, leopards.MySQL, `SQL dialect of the files: mysql or postgres`)
+	ddlCMD.Flags().StringP(`out`, `o`, ``, `output path`)
+	outputFlags(ddlCMD)
+}
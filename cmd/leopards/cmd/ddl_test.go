@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateDDL(t *testing.T) {
+	golden(t, `blog`, generateDDL(t, `postgres`, []string{`blog.sql`}))
+}
+
+func TestGenerateDDLStdin(t *testing.T) {
+	RootCMD.SetIn(strings.NewReader("CREATE TABLE `t` (`id` int NOT NULL, PRIMARY KEY (`id`));"))
+	defer RootCMD.SetIn(nil)
+	output, err := execute(t, `ddl`, `-`, `-o`, t.TempDir()+`/model/model.go`)
+	if err != nil {
+		t.Fatalf("ddl -: %v\n%s", err, output)
+	}
+}
+
+func TestGenerateDDLErrors(t *testing.T) {
+	tests := []struct {
+		dialect string
+		src     string
+		err     string
+	}{
+		{`mysql`, "CREATE TABLE `t` (\n  `id` int,\n  `name` varchar(64) COMMENT 'unterminated\n);", `unterminated`},
+		{`mysql`, "CREATE TABLE `t` (\n  `id` int NOT NULL,\n  PRIMARY KEY (,)\n);", `:3: `},
+		{`mysql`, "CREATE VIEW v AS SELECT 1;", `no CREATE TABLE statement`},
+		{`sqlite`, "CREATE TABLE t (id int);", `unknown dialect "sqlite"`},
+	}
+	for _, tt := range tests {
+		RootCMD.SetIn(strings.NewReader(tt.src))
+		_, err := execute(t, `ddl`, `-d`, tt.dialect, `-`, `-o`, t.TempDir()+`/model/model.go`)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ddl %q: %v, want an error with %q", tt.src, err, tt.err)
+		}
+	}
+	RootCMD.SetIn(nil)
+}
+
+func TestPgFormatType(t *testing.T) {
+	for typ, want := range map[string]string{
+		`varchar(64)`:                    `character varying(64)`,
+		`character varying`:              `character varying`,
+		`int4`:                           `integer`,
+		`serial`:                         `integer`,
+		`numeric(10,2)`:                  `numeric(10,2)`,
+		`char`:                           `character(1)`,
+		`timestamptz(3)`:                 `timestamp(3) with time zone`,
+		`timestamp without time zone`:    `timestamp without time zone`,
+		`text[]`:                         `text[]`,
+		`varchar(32)[]`:                  `character varying(32)[]`,
+		`integer array`:                  `integer[]`,
+		`public.post_status`:             `post_status`,
+		`billing.currency`:               `billing.currency`,
+		`public.post_status[]`:           `post_status[]`,
+		`jsonb`:                          `jsonb`,
+		`double precision`:               `double precision`,
+		`bit varying(8)`:                 `bit varying(8)`,
+		`time(6) with time zone`:         `time(6) with time zone`,
+		`timestamp(0) without time zone`: `timestamp(0) without time zone`,
+	} {
+		if got := pgFormatType(typ); got != want {
+			t.Errorf("pgFormatType(%q) = %q, want %q", typ, got, want)
+		}
+	}
+}
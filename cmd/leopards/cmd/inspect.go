@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/liqiongfan/leopards"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Dump is the schema of a database printed by the inspect command.
+type Dump struct {
+	Dialect  string       `json:"dialect" yaml:"dialect"`
+	Database string       `json:"database" yaml:"database"`
+	Tables   []*DumpTable `json:"tables" yaml:"tables"`
+}
+
+// DumpTable describes a table of the Dump.
+type DumpTable struct {
+	Schema      string            `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Name        string            `json:"name" yaml:"name"`
+	Comment     string            `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Columns     []*DumpColumn     `json:"columns" yaml:"columns"`
+	PrimaryKey  []string          `json:"primary_key,omitempty" yaml:"primary_key,omitempty"`
+	Indexes     []*DumpIndex      `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+	ForeignKeys []*DumpForeignKey `json:"foreign_keys,omitempty" yaml:"foreign_keys,omitempty"`
+}
+
+// DumpColumn describes a column of a DumpTable.
+type DumpColumn struct {
+	Name          string   `json:"name" yaml:"name"`
+	Type          string   `json:"type" yaml:"type"`
+	Nullable      bool     `json:"nullable" yaml:"nullable"`
+	Default       *string  `json:"default,omitempty" yaml:"default,omitempty"`
+	AutoIncrement bool     `json:"auto_increment,omitempty" yaml:"auto_increment,omitempty"`
+	Unique        bool     `json:"unique,omitempty" yaml:"unique,omitempty"`
+	Comment       string   `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Enum          []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+}
+
+// DumpIndex describes a secondary index of a DumpTable.
+type DumpIndex struct {
+	Name    string   `json:"name" yaml:"name"`
+	Unique  bool     `json:"unique" yaml:"unique"`
+	Columns []string `json:"columns" yaml:"columns"`
+}
+
+// DumpForeignKey describes a foreign key of a DumpTable.
+type DumpForeignKey struct {
+	Name       string   `json:"name" yaml:"name"`
+	Columns    []string `json:"columns" yaml:"columns"`
+	RefTable   string   `json:"ref_table" yaml:"ref_table"`
+	RefColumns []string `json:"ref_columns" yaml:"ref_columns"`
+	OnUpdate   string   `json:"on_update,omitempty" yaml:"on_update,omitempty"`
+	OnDelete   string   `json:"on_delete,omitempty" yaml:"on_delete,omitempty"`
+}
+
+// dump converts the data model of the templates to the Dump.
+func dump(s *Schema) *Dump {
+	d := &Dump{Dialect: s.Dialect, Database: s.Database, Tables: make([]*DumpTable, 0, len(s.Tables))}
+	for _, t := range s.Tables {
+		table := &DumpTable{Schema: t.Schema, Name: t.Name, Comment: t.Comment}
+		for _, c := range t.Columns {
+			typ := c.ColumnType
+			if c.Size > 0 && !strings.Contains(typ, `(`) {
+				typ = fmt.Sprintf(`%s(%d)`, typ, c.Size)
+			}
+			table.Columns = append(table.Columns, &DumpColumn{
+				Name:          c.Name,
+				Type:          typ,
+				Nullable:      c.Nullable,
+				Default:       c.Default,
+				AutoIncrement: c.AutoIncrement,
+				Unique:        c.Unique,
+				Comment:       c.Comment,
+				Enum:          c.Enum,
+			})
+		}
+		for _, c := range t.PrimaryKey {
+			table.PrimaryKey = append(table.PrimaryKey, c.Name)
+		}
+		for _, idx := range t.SecondaryIndexes() {
+			table.Indexes = append(table.Indexes, &DumpIndex{Name: idx.Name, Unique: idx.Unique, Columns: idx.Columns})
+		}
+		for _, fk := range t.ForeignKeys {
+			table.ForeignKeys = append(table.ForeignKeys, &DumpForeignKey{
+				Name:       fk.Name,
+				Columns:    fk.Columns,
+				RefTable:   fk.RefTable,
+				RefColumns: fk.RefColumns,
+				OnUpdate:   fk.OnUpdate,
+				OnDelete:   fk.OnDelete,
+			})
+		}
+		d.Tables = append(d.Tables, table)
+	}
+	return d
+}
+
+// markdown writes the Dump as a data dictionary in Markdown.
+func (d *Dump) markdown(w io.Writer) {
+	fmt.Fprintf(w, "# %s\n", d.Database)
+	for _, t := range d.Tables {
+		fmt.Fprintf(w, "\n## %s\n\n", t.Name)
+		if t.Comment != `` {
+			fmt.Fprintf(w, "%s\n\n", t.Comment)
+		}
+		fmt.Fprint(w, "| Column | Type | Nullable | Default | Key | Comment |\n| --- | --- | --- | --- | --- | --- |\n")
+		for _, c := range t.Columns {
+			nullable, key := `NO`, ``
+			if c.Nullable {
+				nullable = `YES`
+			}
+			switch {
+			case contains(t.PrimaryKey, c.Name):
+				key = `PRI`
+			case c.Unique:
+				key = `UNI`
+			}
+			def := deref(c.Default)
+			if c.AutoIncrement && def == `` {
+				def = `auto increment`
+			}
+			fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s |\n", cell(c.Name), cell(c.Type), nullable, cell(def), key, cell(c.Comment))
+		}
+		if len(t.Indexes) > 0 {
+			fmt.Fprint(w, "\n### Indexes\n\n| Name | Unique | Columns |\n| --- | --- | --- |\n")
+			for _, idx := range t.Indexes {
+				unique := `NO`
+				if idx.Unique {
+					unique = `YES`
+				}
+				fmt.Fprintf(w, "| %s | %s | %s |\n", cell(idx.Name), unique, cell(strings.Join(idx.Columns, `, `)))
+			}
+		}
+		if len(t.ForeignKeys) > 0 {
+			fmt.Fprint(w, "\n### Foreign keys\n\n| Name | Columns | References | On update | On delete |\n| --- | --- | --- | --- | --- |\n")
+			for _, fk := range t.ForeignKeys {
+				ref := fmt.Sprintf(`%s(%s)`, fk.RefTable, strings.Join(fk.RefColumns, `, `))
+				fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n", cell(fk.Name), cell(strings.Join(fk.Columns, `, `)), cell(ref), fk.OnUpdate, fk.OnDelete)
+			}
+		}
+	}
+}
+
+// cell escapes the text of a Markdown table cell.
+func cell(s string) string {
+	return strings.NewReplacer(`|`, `\|`, "\r\n", `<br>`, "\n", `<br>`).Replace(s)
+}
+
+// encode writes the Dump in the given format: json, yaml or markdown.
+func (d *Dump) encode(w io.Writer, format string) error {
+	switch format {
+	case `json`:
+		e := json.NewEncoder(w)
+		e.SetIndent(``, `  `)
+		return e.Encode(d)
+	case `yaml`, `yml`:
+		e := yaml.NewEncoder(w)
+		e.SetIndent(2)
+		if err := e.Encode(d); err != nil {
+			return err
+		}
+		return e.Close()
+	case `markdown`, `md`:
+		d.markdown(w)
+		return nil
+	default:
+		return fmt.Errorf(`unknown format %q, expect json, yaml or markdown`, format)
+	}
+}
+
+// inspect prints the schema of the tables of the database. The database may
+// be omitted for the database of the DSN.
+func inspect(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	// The Go types are not printed.
+	cfg.warn = io.Discard
+	dialect := args[0]
+	switch dialect {
+	case leopards.MySQL, leopards.Postgres, leopards.SQLite:
+	case `sqlite`:
+		dialect = leopards.SQLite
+	default:
+		return fmt.Errorf(`unknown dialect %q, expect mysql, postgres or sqlite`, dialect)
+	}
+	info, database, err := connection(cmd, cfg, dialect)
+	if err != nil {
+		return err
+	}
+	names := `*`
+	if len(args) > 1 {
+		database = args[1]
+	}
+	if len(args) > 2 {
+		names = args[2]
+	}
+	if database == `` {
+		return errors.New(`missing database, expect the database argument or a DSN with a database`)
+	}
+
+	ctx := cmd.Context()
+	var s *Schema
+	switch dialect {
+	case leopards.MySQL:
+		if info.Port == `` {
+			info.Port = `3306`
+		}
+		tables, err := mysqlIntrospect(ctx, info, database, names)
+		if err != nil {
+			return err
+		}
+		s = mysqlSchema(cfg, database, tables)
+	case leopards.Postgres:
+		if info.Port == `` {
+			info.Port = `5432`
+		}
+		schema, _ := cmd.Flags().GetString(`schema`)
+		tables, err := pgIntrospect(ctx, info, database, schema, names)
+		if err != nil {
+			return err
+		}
+		s = pgSchema(cfg, database, tables)
+	case leopards.SQLite:
+		tables, err := sqliteIntrospect(ctx, database, names)
+		if err != nil {
+			return err
+		}
+		s = sqliteSchema(cfg, database, tables)
+	}
+
+	format, _ := cmd.Flags().GetString(`format`)
+	var b bytes.Buffer
+	if err := dump(s).encode(&b, format); err != nil {
+		return err
+	}
+	output, _ := cmd.Flags().GetString(`out`)
+	if output == `` {
+		_, err := cmd.OutOrStdout().Write(b.Bytes())
+		return err
+	}
+	return os.WriteFile(output, b.Bytes(), 0o644)
+}
+
+var inspectCMD = &cobra.Command{
+	Use:   `inspect mysql|postgres|sqlite [database] [tables] [-h]`,
+	Short: `Print the schema of a database as JSON, YAML or Markdown`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 || len(args) > 3 {
+			return cmd.Help()
+		}
+		return inspect(cmd, args)
+	},
+}
+
+func init() {
+	inspectCMD.Flags().StringP(`user`, `U`, ``, `database username`)
+	inspectCMD.Flags().StringP(`password`, `P`, ``, `database password`)
+	inspectCMD.Flags().StringP(`host`, `H`, ``, `database host`)
+	inspectCMD.Flags().StringP(`port`, `p`, ``, `database port, default 3306 (mysql) or 5432 (postgres)`)
+	inspectCMD.Flags().StringP(`charset`, `C`, `utf8mb4,utf8`, `mysql database charset`)
+	inspectCMD.Flags().StringP(`schema`, `s`, `public`, `PostgreSQL schemas separated by commas, or * for all, default public`)
+	inspectCMD.Flags().StringP(`format`, `f`, `json`, `output format: json, yaml or markdown`)
+	inspectCMD.Flags().StringP(`out`, `o`, ``, `output file, default stdout`)
+	connectionFlags(inspectCMD)
+}
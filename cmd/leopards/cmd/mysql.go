@@ -1,14 +1,8 @@
 package cmd
 
 import (
-	bytes2 "bytes"
-	"errors"
-	"os"
-	"path/filepath"
+	"context"
 	"strings"
-	"time"
-
-	"text/template"
 
 	"github.com/liqiongfan/leopards"
 	"github.com/spf13/cobra"
@@ -18,6 +12,7 @@ type Column struct {
 	TableSchema            string  `json:"TABLE_SCHEMA"`
 	TableName              string  `json:"TABLE_NAME"`
 	ColumnName             *string `json:"COLUMN_NAME"`
+	ColumnDefault          *string `json:"COLUMN_DEFAULT"`
 	IsNullable             string  `json:"IS_NULLABLE"`
 	DataType               *string `json:"DATA_TYPE"`
 	CharacterMaximumLength *int64  `json:"CHARACTER_MAXIMUM_LENGTH"`
@@ -31,36 +26,236 @@ type Column struct {
 	CamelName              *string
 }
 
+// TemplateStruct is the default template of the generated files,
+// executed with the Schema of the tables.
 const TemplateStruct = `
-//
-// created by leopards at: {{ .Time }}
-// 
+{{- range $e := .Enums }}
+type {{ $e.Name }} string
+
+const (
+{{- range $v := $e.Values }}
+	{{ $e.Prefix }}{{ camel $v }} {{ $e.Name }} = {{ quote $v }}
+{{- end }}
+)
+{{ end }}
+{{- range $t := .Tables }}
+// {{ $t.GoName }}Table {{ comment $t.Comment }}
+const {{ $t.GoName }}Table = {{ quote $t.Name }}
+
+// {{ $t.GoName }} {{ comment $t.Comment }}
+type {{ $t.GoName }} struct {
+{{- range $t.Columns }}
+	{{ .GoName }} {{ .GoType }} ` + "`{{ .Tag }}`" + `{{ with .Comment }} // {{ comment . }}{{ end }}
+{{- end }}
+}
+
+// {{ $t.GoName }} columns.
+const (
+{{- range $t.Columns }}
+	{{ $t.GoName }}Column{{ .GoName }} = {{ quote .Name }}
+{{- end }}
+)
+
+// {{ $t.GoName }}Where holds the typed predicates of the {{ $t.Name }} columns.
+var {{ $t.GoName }}Where = struct {
+{{- range $t.Columns }}
+	{{ .GoName }} leopards.Field[{{ .FieldType }}]
+{{- end }}
+}{
+{{- range $t.Columns }}
+	{{ .GoName }}: {{ $t.GoName }}Column{{ .GoName }},
+{{- end }}
+}
 
-{{ range $key, $value := .Data }}
-{{- range .Columns }} {{ enum .ColumnName .DataType .ColumnType }}    
+// {{ $t.GoName }}Schema describes the {{ $t.Name }} table.
+var {{ $t.GoName }}Schema = &leopards.TableSchema{
+	Name: {{ quote $t.Name }},
+	Columns: []*leopards.ColumnSchema{
+{{- range $t.Columns }}
+		{Name: {{ quote .Name }}, Type: {{ quote .ColumnType }}
+			{{- if .Nullable }}, Nullable: true{{ end }}
+			{{- if .Unique }}, Unique: true{{ end }}
+			{{- if .AutoIncrement }}, AutoIncrement: true{{ else if .Default }}, Default: {{ quote (deref .Default) }}{{ end }}},
 {{- end }}
+	},
+{{- with $t.PrimaryKey }}
+	PrimaryKey: []string{ {{- range $i, $c := . }}{{ if $i }}, {{ end }}{{ quote $c.Name }}{{ end -}} },
+{{- end }}
+{{- with $t.SecondaryIndexes }}
+	Indexes: []*leopards.IndexSchema{
+{{- range . }}
+		{Name: {{ quote .Name }}{{ if .Unique }}, Unique: true{{ end }}, Columns: []string{ {{- quotes .Columns -}} }},
+{{- end }}
+	},
+{{- end }}
+{{- with $t.ForeignKeys }}
+	ForeignKeys: []*leopards.ForeignKeySchema{
+{{- range . }}
+		{Name: {{ quote .Name }}, Columns: []string{ {{- quotes .Columns -}} }, RefTable: {{ quote .RefTable }}, RefColumns: []string{ {{- quotes .RefColumns -}} }, OnUpdate: {{ quote .OnUpdate }}, OnDelete: {{ quote .OnDelete }}},
+{{- end }}
+	},
+{{- end }}
+}
+
+// Schema returns the schema of the {{ $t.Name }} table.
+func ({{ $t.GoName }}) Schema() *leopards.TableSchema { return {{ $t.GoName }}Schema }
+{{- $r := receiver $t.GoName }}
+{{- range $e := $t.Edges }}
+{{ if $e.Through }}
+// Query{{ $e.Name }} queries the {{ $e.RefTable }} rows of the {{ $t.Name }} row, through {{ $e.Through }}.
+func ({{ $r }} *{{ $t.GoName }}) Query{{ $e.Name }}(db *leopards.DB) *leopards.Selector {
+	ref, join := db.Table({{ $e.RefGoName }}Table), db.Table({{ $e.ThroughGoName }}Table)
+	return db.Query().Select(ref.C("*")).FromTable(ref).
+		Join(join)
+		{{- range $i, $c := $e.ThroughRefColumns }}.On(join.C({{ quote $c }}), ref.C({{ quote (index $e.RefColumns $i) }})){{ end }}
+		{{- range $i, $c := $e.ThroughColumns }}.
+		Where(leopards.EQ(join.C({{ quote $c }}), {{ $r }}.{{ index $e.Fields $i }})){{ end }}
+}
+{{- else }}
+// Query{{ $e.Name }} queries the {{ $e.RefTable }} {{ if $e.Unique }}row{{ else }}rows{{ end }} of the {{ $t.Name }} row.
+func ({{ $r }} *{{ $t.GoName }}) Query{{ $e.Name }}(db *leopards.DB) *leopards.Selector {
+	return db.Query().From({{ $e.RefGoName }}Table)
+		{{- range $i, $c := $e.RefColumns }}.
+		Where(leopards.EQ({{ quote $c }}, {{ $r }}.{{ index $e.Fields $i }})){{ end }}
+}
+{{- end }}
+{{- end }}
+
+{{- if and $.Repo $t.PrimaryKey }}
 
-// {{ camel $value.TableName }}Table {{ $value.Comment }}
-const {{ camel $value.TableName }}Table = "{{ $value.TableName }}"
+// {{ $t.GoName }}Repository reads and writes the {{ $t.Name }} rows.
+type {{ $t.GoName }}Repository interface {
+	Get(ctx context.Context{{ template "keys" $t }}) (*{{ $t.GoName }}, error)
+	List(ctx context.Context, predicates ...*leopards.Predicate) ([]{{ $t.GoName }}, error)
+	Count(ctx context.Context, predicates ...*leopards.Predicate) (int64, error)
+	Create(ctx context.Context, v *{{ $t.GoName }}) error
+	Update(ctx context.Context, v *{{ $t.GoName }}) error
+	Delete(ctx context.Context{{ template "keys" $t }}) error
+	Upsert(ctx context.Context, v *{{ $t.GoName }}) (bool, error)
+}
+
+// {{ $t.GoName }}Repo implements {{ $t.GoName }}Repository with the leopards builders.
+type {{ $t.GoName }}Repo struct {
+	db *leopards.DB
+}
+
+var _ {{ $t.GoName }}Repository = (*{{ $t.GoName }}Repo)(nil)
+
+// New{{ $t.GoName }}Repo returns a repository of the {{ $t.Name }} rows.
+func New{{ $t.GoName }}Repo(db *leopards.DB) *{{ $t.GoName }}Repo {
+	return &{{ $t.GoName }}Repo{db: db}
+}
+
+// Get returns the {{ $t.Name }} row with the given primary key, or an error matching sql.ErrNoRows.
+func (r *{{ $t.GoName }}Repo) Get(ctx context.Context{{ template "keys" $t }}) (*{{ $t.GoName }}, error) {
+	v := new({{ $t.GoName }})
+	err := r.db.Query().From({{ $t.GoName }}Table){{ template "where" $t }}.Limit(1).ScanInto(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// List returns the {{ $t.Name }} rows matching all the predicates.
+func (r *{{ $t.GoName }}Repo) List(ctx context.Context, predicates ...*leopards.Predicate) ([]{{ $t.GoName }}, error) {
+	s := r.db.Query().From({{ $t.GoName }}Table)
+	if len(predicates) > 0 {
+		s.Where(leopards.And(predicates...))
+	}
+	rows := make([]{{ $t.GoName }}, 0)
+	return rows, s.Scan(ctx, &rows)
+}
+
+// Count returns the number of {{ $t.Name }} rows matching all the predicates.
+func (r *{{ $t.GoName }}Repo) Count(ctx context.Context, predicates ...*leopards.Predicate) (int64, error) {
+	s := r.db.Query().From({{ $t.GoName }}Table)
+	if len(predicates) > 0 {
+		s.Where(leopards.And(predicates...))
+	}
+	return s.CountRows(ctx)
+}
+
+// Create inserts the {{ $t.Name }} row, and sets its auto-increment primary key.
+func (r *{{ $t.GoName }}Repo) Create(ctx context.Context, v *{{ $t.GoName }}) error {
+	_, err := r.db.Insert().Table({{ $t.GoName }}Table).Model(v).Save(ctx)
+	return err
+}
+
+// Update updates the {{ $t.Name }} row with the primary key of v.
+func (r *{{ $t.GoName }}Repo) Update(ctx context.Context, v *{{ $t.GoName }}) error {
+	_, err := r.db.Update().Table({{ $t.GoName }}Table).Model(v).Save(ctx)
+	return err
+}
+
+// Delete deletes the {{ $t.Name }} row with the given primary key.
+func (r *{{ $t.GoName }}Repo) Delete(ctx context.Context{{ template "keys" $t }}) error {
+	_, err := r.db.Delete().Table({{ $t.GoName }}Table){{ template "where" $t }}.Exec(ctx)
+	return err
+}
 
-// {{ camel $value.TableName }} {{ $value.Comment }}
-type {{ camel $value.TableName }} struct { 
-{{ range .Columns }}    {{ camel .CamelName }}{{ pad (camel .ColumnName) $value.MaxColumnLength }} {{ type .DataType .ColumnType .IsNullable }}{{ pad (type .DataType .ColumnType .IsNullable) $value.MaxTypeLength }}  {{ tag .ColumnName .ColumnComment $value.MaxNameLength  }}
-{{ end -}} 
+// Upsert inserts the {{ $t.Name }} row, or updates the row with its primary key. It reports whether the row was inserted.
+func (r *{{ $t.GoName }}Repo) Upsert(ctx context.Context, v *{{ $t.GoName }}) (bool, error) {
+	return r.db.Upsert(ctx, v)
 }
+{{- end }}
+
+// leopards:begin {{ $t.GoName }}
+// leopards:end {{ $t.GoName }}
 {{ end }}
-`
+{{- define "keys" }}{{ range .PrimaryKey }}, {{ param .Name }} {{ .FieldType }}{{ end }}{{ end }}
+{{- define "where" }}{{ range .PrimaryKey }}.Where(leopards.EQ({{ quote .Name }}, {{ param .Name }})){{ end }}{{ end }}`
 
 type Table struct {
-	TableName                                     string `json:"TABLE_NAME"`
-	Comment                                       string `json:"TABLE_COMMENT"`
-	Columns                                       []Column
-	MaxColumnLength, MaxTypeLength, MaxNameLength int
+	TableName   string `json:"TABLE_NAME"`
+	Comment     string `json:"TABLE_COMMENT"`
+	Columns     []Column
+	Indexes     []Index
+	ForeignKeys []KeyColumn
+}
+
+// Index is an indexed column of information_schema.statistics.
+type Index struct {
+	IndexName  string  `json:"INDEX_NAME"`
+	NonUnique  int     `json:"NON_UNIQUE"`
+	SeqInIndex int     `json:"SEQ_IN_INDEX"`
+	ColumnName *string `json:"COLUMN_NAME"`
+}
+
+// KeyColumn is a foreign key column of information_schema.key_column_usage,
+// with the rules of its information_schema.referential_constraints row.
+type KeyColumn struct {
+	ConstraintName       string `json:"CONSTRAINT_NAME"`
+	ColumnName           string `json:"COLUMN_NAME"`
+	ReferencedTableName  string `json:"REFERENCED_TABLE_NAME"`
+	ReferencedColumnName string `json:"REFERENCED_COLUMN_NAME"`
+	UpdateRule           string `json:"UPDATE_RULE"`
+	DeleteRule           string `json:"DELETE_RULE"`
 }
 
 func generate(cmd *cobra.Command, args []string) error {
-	info := getInfo(cmd)
-	dsn := leopards.DSN(&leopards.OpenOptions{
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	info, database, err := connection(cmd, cfg, leopards.MySQL)
+	if err != nil {
+		return err
+	}
+	database, names, err := databaseArgs(args, database)
+	if err != nil {
+		return err
+	}
+	tables, err := mysqlIntrospect(cmd.Context(), info, database, names)
+	if err != nil {
+		return err
+	}
+	return render(cmd, mysqlSchema(cfg, database, tables))
+}
+
+// mysqlIntrospect reads the tables of the database from information_schema. The
+// names are separated by commas, or * for all the tables of the database.
+func mysqlIntrospect(ctx context.Context, info *Info, database, names string) ([]Table, error) {
+	db, err := leopards.OpenOptions{
 		User:     info.User,
 		Password: info.Password,
 		Host:     info.Host,
@@ -69,18 +264,17 @@ func generate(cmd *cobra.Command, args []string) error {
 		Database: `information_schema`,
 		Dialect:  leopards.MySQL,
 		Charset:  info.Charset,
-	})
-
-	db, err := leopards.Open(leopards.MySQL, dsn)
+		Params:   info.Params,
+	}.Open()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	query := db.Query().
 		From(`tables`).
-		Where(leopards.EQ(`TABLE_SCHEMA`, args[0]))
-	if args[1] != `*` {
-		tableNames := strings.Split(args[1], `,`)
+		Where(leopards.EQ(`TABLE_SCHEMA`, database))
+	if names != `*` {
+		tableNames := strings.Split(names, `,`)
 		ins := make([]any, 0, 20)
 		for _, name := range tableNames {
 			ins = append(ins, strings.TrimSpace(name))
@@ -90,122 +284,139 @@ func generate(cmd *cobra.Command, args []string) error {
 
 	tables := make([]Table, 0, 20)
 	err = query.
-		Scan(cmd.Context(), &tables)
+		Scan(ctx, &tables)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	needImportTime := false
-
 	for i, table := range tables {
 		columns := make([]Column, 0, 20)
 		err = db.Query().
 			From(`columns`).
-			Where(leopards.EQ(`TABLE_SCHEMA`, args[0])).
+			Where(leopards.EQ(`TABLE_SCHEMA`, database)).
 			Where(leopards.EQ(`TABLE_NAME`, table.TableName)).
 			OrderBy(leopards.Asc(`ORDINAL_POSITION`)).
-			Scan(cmd.Context(), &columns)
+			Scan(ctx, &columns)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		tables[i].Columns = columns
+
+		err = db.Query().
+			Select(`INDEX_NAME`, `NON_UNIQUE`, `SEQ_IN_INDEX`, `COLUMN_NAME`).
+			From(`statistics`).
+			Where(leopards.EQ(`TABLE_SCHEMA`, database)).
+			Where(leopards.EQ(`TABLE_NAME`, table.TableName)).
+			OrderBy(leopards.Asc(`INDEX_NAME`), leopards.Asc(`SEQ_IN_INDEX`)).
+			Scan(ctx, &tables[i].Indexes)
+		if err != nil {
+			return nil, err
 		}
-		flags := make(map[string]struct{}, len(columns))
-		for j, column := range columns {
-			camelName := camel(column.ColumnName)
-			column.CamelName = column.ColumnName
-			columns[j].CamelName = column.ColumnName
-			if _, ok := flags[camelName]; ok {
-				tName := snake(camelName)
-				column.ColumnName = &tName
-				columns[j].CamelName = &tName
-			} else {
-				flags[camelName] = struct{}{}
-			}
 
-			if strings.Contains(Type(column.DataType, column.ColumnComment, column.IsNullable), `time.Time`) {
-				needImportTime = true
+		k := db.Table(`key_column_usage`).As(`k`)
+		r := db.Table(`referential_constraints`).As(`r`)
+		err = db.Query().
+			Select(
+				k.C(`CONSTRAINT_NAME`),
+				k.C(`COLUMN_NAME`),
+				k.C(`REFERENCED_TABLE_NAME`),
+				k.C(`REFERENCED_COLUMN_NAME`),
+				r.C(`UPDATE_RULE`),
+				r.C(`DELETE_RULE`),
+			).
+			FromTable(k).
+			Join(r).
+			On(k.C(`CONSTRAINT_SCHEMA`), r.C(`CONSTRAINT_SCHEMA`)).
+			On(k.C(`CONSTRAINT_NAME`), r.C(`CONSTRAINT_NAME`)).
+			Where(leopards.EQ(k.C(`TABLE_SCHEMA`), database)).
+			Where(leopards.EQ(k.C(`TABLE_NAME`), table.TableName)).
+			OrderBy(leopards.Asc(k.C(`CONSTRAINT_NAME`)), leopards.Asc(k.C(`ORDINAL_POSITION`))).
+			Scan(ctx, &tables[i].ForeignKeys)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
+
+// mysqlSchema converts the MySQL tables to the data model of the templates.
+func mysqlSchema(cfg *Config, database string, tables []Table) *Schema {
+	s := &Schema{Dialect: leopards.MySQL, Database: database}
+	for _, table := range tables {
+		t := &TableInfo{Schema: database, Name: table.TableName, Comment: table.Comment}
+		for _, column := range table.Columns {
+			c := &ColumnInfo{
+				Name:          *column.ColumnName,
+				Comment:       column.ColumnComment,
+				ColumnType:    column.ColumnType,
+				Nullable:      column.IsNullable == `YES`,
+				PrimaryKey:    column.ColumnKey == `PRI`,
+				Unique:        column.ColumnKey == `UNI`,
+				AutoIncrement: column.Extra != nil && strings.Contains(*column.Extra, `auto_increment`),
+				Default:       mysqlDefault(column),
+				Enum:          enumValues(column.DataType, column.ColumnType),
 			}
-			if length := len(camel(column.CamelName)); length > tables[i].MaxColumnLength {
-				tables[i].MaxColumnLength = length
+			if column.DataType != nil {
+				c.DataType = *column.DataType
 			}
-			if length := len(Type(column.DataType, column.ColumnType, column.IsNullable)); length > tables[i].MaxTypeLength {
-				tables[i].MaxTypeLength = length
+			if column.CharacterMaximumLength != nil {
+				c.Size = *column.CharacterMaximumLength
 			}
-			if length := len(*column.ColumnName); length > tables[i].MaxNameLength {
-				tables[i].MaxNameLength = length
+			cfg.columnTypes(t.Name, c, func(c *ColumnInfo) (string, bool) { return mysqlType(c.DataType, c.ColumnType) })
+			t.Columns = append(t.Columns, c)
+			if c.PrimaryKey {
+				t.PrimaryKey = append(t.PrimaryKey, c)
 			}
 		}
-		tables[i].Columns = columns
-	}
-
-	output, err := cmd.Flags().GetString(`out`)
-	if err != nil {
-		return err
-	}
-
-	if output == `` {
-		return trace(errors.New(`--out: output file not specified`))
-	}
-
-	t := template.New(`template`)
-
-	t = t.Funcs(map[string]any{
-		`camel`: camel,
-		`type`:  Type,
-		`tag`:   tag,
-		`pad`:   pad,
-		`enum`:  enum,
-	})
-	t, err = t.Parse(TemplateStruct)
-	if err != nil {
-		return trace(err)
-	}
-
-	bytes := bytes2.NewBuffer(nil)
-
-	err = t.Execute(bytes, map[string]any{
-		`Time`: time.Now().Format(`2006-01-02 15:04:05`),
-		`Data`: tables,
-	})
-	if err != nil {
-		return err
-	}
-
-	f, err := os.OpenFile(output, os.O_CREATE|os.O_RDWR|os.O_APPEND, os.ModePerm)
-	if err != nil {
-		return err
-	}
-	fi, err := f.Stat()
-	if err != nil {
-		return err
-	}
-
-	if fi.Size() == 0 {
-		packageName := ``
-		if filepath.Dir(output) == `.` {
-			wd, _ := os.Getwd()
-			packageName = filepath.Base(wd)
-		} else {
-			packageName = filepath.Base(filepath.Dir(output))
-			_ = os.MkdirAll(filepath.Dir(output), os.ModePerm)
+		for _, idx := range table.Indexes {
+			if idx.ColumnName != nil {
+				t.addIndex(idx.IndexName, idx.NonUnique == 0, idx.IndexName == `PRIMARY`, *idx.ColumnName)
+			}
 		}
-
-		_, _ = f.WriteString(`package ` + packageName)
-		if needImportTime {
-			_, _ = f.WriteString("\n\nimport \"time\"")
+		for _, fk := range table.ForeignKeys {
+			t.addForeignKey(fk.ConstraintName, fk.ColumnName, fk.ReferencedTableName, fk.ReferencedColumnName, fk.UpdateRule, fk.DeleteRule)
 		}
+		t.keys()
+		t.goNames()
+		cfg.tags(t)
+		s.Tables = append(s.Tables, t)
 	}
+	s.edges()
+	s.enums()
+	return s
+}
 
-	defer f.Close()
-	_, _ = f.WriteString(bytes.String())
+// mysqlQuoted are the data types whose literal default values are quoted.
+var mysqlQuoted = map[string]struct{}{
+	`char`: {}, `varchar`: {}, `tinytext`: {}, `text`: {}, `mediumtext`: {}, `longtext`: {},
+	`enum`: {}, `set`: {}, `date`: {}, `datetime`: {}, `timestamp`: {}, `time`: {}, `year`: {},
+	`binary`: {}, `varbinary`: {},
+}
 
-	return nil
+// mysqlDefault returns the default value expression of the column. MySQL
+// reports the literal values unquoted, and the expressions with the
+// DEFAULT_GENERATED extra, except for CURRENT_TIMESTAMP before MySQL 8.
+func mysqlDefault(column Column) *string {
+	def := column.ColumnDefault
+	if def == nil || *def == `NULL` || column.DataType == nil {
+		return nil
+	}
+	_, quoted := mysqlQuoted[*column.DataType]
+	switch {
+	case column.Extra != nil && strings.Contains(*column.Extra, `DEFAULT_GENERATED`):
+	case strings.HasPrefix(*def, `'`), strings.HasPrefix(strings.ToUpper(*def), `CURRENT_TIMESTAMP`):
+	case quoted:
+		v := `'` + strings.ReplaceAll(*def, `'`, `''`) + `'`
+		return &v
+	}
+	return def
 }
 
 var mysqlCMD = &cobra.Command{
-	Use:   `mysql database table [-h]`,
+	Use:   `mysql [database] table [-h]`,
 	Short: `A MySQL schema generate tool for leopards`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) != 2 {
+		if len(args) < 1 || len(args) > 2 {
 			return cmd.Help()
 		}
 		return generate(cmd, args)
@@ -219,4 +430,6 @@ func init() {
 	mysqlCMD.Flags().StringP(`port`, `p`, `3306`, `mysql database port`)
 	mysqlCMD.Flags().StringP(`charset`, `C`, `utf8mb4,utf8`, `mysql database charset`)
 	mysqlCMD.Flags().StringP(`out`, `o`, ``, `output path`)
+	connectionFlags(mysqlCMD)
+	outputFlags(mysqlCMD)
 }
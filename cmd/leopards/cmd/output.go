@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// Markers of the regions of user code preserved when the files are
+// generated again. Each table has its own region, and the imports
+// region holds the imports of the code in the other regions.
+const (
+	regionBegin   = `// leopards:begin `
+	regionEnd     = `// leopards:end `
+	regionImports = `imports`
+)
+
+// generated is the beginning of the first line of the generated files.
+// The files of the --out directory starting with it are managed by
+// leopards, and removed with --split when their tables are dropped.
+const generated = `// Code generated by leopards.`
+
+// header is the beginning of the generated files.
+const header = generated + ` Only the code between the leopards:begin and
+// leopards:end comments may be edited: it is kept when the file is generated again.
+
+package %s
+
+%s
+` + regionBegin + regionImports + `
+` + regionEnd + regionImports + `
+`
+
+// outputFlags adds the flags of the output files to the command.
+func outputFlags(c *cobra.Command) {
+	c.Flags().Bool(`split`, false, `generate one file per table into the --out directory`)
+	c.Flags().Bool(`check`, false, `exit with an error if the generated files are out of date, without writing them`)
+	c.Flags().String(`template`, ``, `directory of custom templates (*.tmpl) executed instead of the default one`)
+	c.Flags().Bool(`repo`, false, `generate a repository (DAO) of each table with a primary key`)
+	c.Flags().StringSlice(`tags`, nil, `struct tags of the fields: json, db, leopard (default json)`)
+}
+
+// genFile is a file to generate.
+type genFile struct {
+	path     string       // path of the file.
+	template string       // name of the custom template, empty for the default one.
+	tables   []*TableInfo // tables of the file.
+}
+
+// render executes the templates with the schema and writes the generated files.
+// The default template generates the --out file, or one file per table in the
+// --out directory with --split. Each custom template of the --template directory
+// generates a file of the --out directory, named after the template without its
+// .tmpl extension, and prefixed with the table name with --split. The files are
+// rewritten in place, and the user code of their regions is kept. With --split,
+// the generated files of the --out directory that are no longer generated (e.g.
+// of dropped tables) are removed, unless their regions hold user code.
+func render(cmd *cobra.Command, s *Schema) error {
+	output, err := cmd.Flags().GetString(`out`)
+	if err != nil {
+		return err
+	}
+	return renderTo(cmd, s, output)
+}
+
+// renderTo is like render, with the given output path instead of --out.
+func renderTo(cmd *cobra.Command, s *Schema, output string) error {
+	if output == `` {
+		return errors.New(`--out: output file not specified`)
+	}
+	split, _ := cmd.Flags().GetBool(`split`)
+	check, _ := cmd.Flags().GetBool(`check`)
+	dir, _ := cmd.Flags().GetString(`template`)
+	s.Repo, _ = cmd.Flags().GetBool(`repo`)
+
+	t, names, err := loadTemplates(dir)
+	if err != nil {
+		return err
+	}
+	var files []*genFile
+	add := func(path, name string, table *TableInfo) {
+		for _, f := range files {
+			if f.path == path {
+				f.tables = append(f.tables, table)
+				return
+			}
+		}
+		files = append(files, &genFile{path: path, template: name, tables: []*TableInfo{table}})
+	}
+	for _, table := range s.Tables {
+		switch {
+		case dir == `` && split:
+			add(filepath.Join(output, table.Name+`.go`), ``, table)
+		case dir == ``:
+			add(output, ``, table)
+		}
+		for _, name := range names {
+			base := strings.TrimSuffix(name, `.tmpl`)
+			if split {
+				base = table.Name + `_` + base
+			}
+			add(filepath.Join(output, base), name, table)
+		}
+	}
+
+	var orphans []string
+	if split {
+		if orphans, err = orphanFiles(output, files); err != nil {
+			return err
+		}
+	}
+	var stale []string
+	for _, f := range files {
+		old, err := os.ReadFile(f.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		data := &Schema{
+			Dialect:  s.Dialect,
+			Database: s.Database,
+			Package:  packageName(f.path, old),
+			Tables:   f.tables,
+			Repo:     s.Repo,
+		}
+		if split {
+			data.Table = f.tables[0]
+		}
+		for _, e := range s.Enums {
+			for _, t := range f.tables {
+				if e.Table == t {
+					data.Enums = append(data.Enums, e)
+				}
+			}
+		}
+		src, err := generateFile(t, f, data, old)
+		if err != nil {
+			return err
+		}
+		switch {
+		case check && !bytes.Equal(src, old):
+			stale = append(stale, f.path)
+		case check:
+		default:
+			if err := os.MkdirAll(filepath.Dir(f.path), os.ModePerm); err != nil {
+				return err
+			}
+			if err := os.WriteFile(f.path, src, 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	if !check {
+		return removeOrphans(cmd, orphans)
+	}
+	var errs []string
+	if len(stale) > 0 {
+		errs = append(errs, `generated files are out of date: `+strings.Join(stale, `, `))
+	}
+	if len(orphans) > 0 {
+		errs = append(errs, `orphan files, no longer generated: `+strings.Join(orphans, `, `))
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, `; `))
+	}
+	return nil
+}
+
+// orphanFiles returns the files of the directory generated by leopards,
+// which are not generated anymore.
+func orphanFiles(dir string, files []*genFile) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var orphans []string
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if e.IsDir() || generates(files, path) {
+			continue
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(b, []byte(generated)) {
+			orphans = append(orphans, path)
+		}
+	}
+	return orphans, nil
+}
+
+// generates reports if the path is one of the generated files.
+func generates(files []*genFile, path string) bool {
+	for _, f := range files {
+		if filepath.Clean(f.path) == path {
+			return true
+		}
+	}
+	return false
+}
+
+// removeOrphans removes the orphan files, except the ones holding
+// user code in their regions, which are reported and kept.
+func removeOrphans(cmd *cobra.Command, orphans []string) error {
+	for _, path := range orphans {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		kept := false
+		for _, code := range parseRegions(b) {
+			kept = kept || strings.TrimSpace(code) != ``
+		}
+		if kept {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s is no longer generated, kept for the user code of its regions\n", path)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "removed %s, no longer generated\n", path)
+	}
+	return nil
+}
+
+// loadTemplates parses the default template, or the custom templates of the
+// directory. It returns the names of the custom templates that generate files,
+// which are the *.tmpl files whose names do not start with an underscore. The
+// others hold shared definitions only.
+func loadTemplates(dir string) (*template.Template, []string, error) {
+	t := template.New(`leopards`).Funcs(funcs)
+	if dir == `` {
+		t, err := t.Parse(TemplateStruct)
+		return t, nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, `*.tmpl`))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf(`--template: no *.tmpl files in %s`, dir)
+	}
+	if t, err = t.ParseFiles(paths...); err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	for _, path := range paths {
+		if name := filepath.Base(path); !strings.HasPrefix(name, `_`) {
+			names = append(names, name)
+		}
+	}
+	return t, names, nil
+}
+
+// generateFile returns the source of the generated file, keeping the user
+// code of the regions of its previous version. Go files are formatted, and
+// the default template gets the header and the imports of its code.
+func generateFile(t *template.Template, f *genFile, data *Schema, old []byte) ([]byte, error) {
+	body := bytes.NewBuffer(nil)
+	if f.template != `` {
+		if err := t.ExecuteTemplate(body, f.template, data); err != nil {
+			return nil, err
+		}
+		src := mergeRegions(body.String(), parseRegions(old))
+		if !strings.HasSuffix(f.path, `.go`) {
+			return []byte(src), nil
+		}
+		return formatSource(f.path, src)
+	}
+
+	if err := t.Execute(body, data); err != nil {
+		return nil, err
+	}
+	var std, third []string
+	pkgs := data.Imports()
+	if strings.Contains(body.String(), `context.Context`) {
+		pkgs = append([]string{`context`}, pkgs...)
+	}
+	for _, pkg := range pkgs {
+		if strings.Contains(strings.Split(pkg, `/`)[0], `.`) {
+			third = append(third, strconv.Quote(pkg))
+		} else {
+			std = append(std, strconv.Quote(pkg))
+		}
+	}
+	if strings.Contains(body.String(), `leopards.`) {
+		third = append(third, `"github.com/liqiongfan/leopards"`)
+	}
+	imports := std
+	if len(std) > 0 && len(third) > 0 {
+		imports = append(imports, ``)
+	}
+	imports = append(imports, third...)
+	decl := ``
+	switch len(imports) {
+	case 0:
+	case 1:
+		decl = "import " + imports[0] + "\n"
+	default:
+		decl = "import (\n\t" + strings.Join(imports, "\n\t") + "\n)\n"
+	}
+
+	src := fmt.Sprintf(header, data.Package, decl) + body.String()
+	return formatSource(f.path, mergeRegions(src, parseRegions(old)))
+}
+
+// formatSource formats the Go source of the generated file.
+func formatSource(path, src string) ([]byte, error) {
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, path, err)
+	}
+	return formatted, nil
+}
+
+// packageName returns the package name of the existing file,
+// or the name of the directory of the generated file.
+func packageName(path string, old []byte) string {
+	s := bufio.NewScanner(bytes.NewReader(old))
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); strings.HasPrefix(line, `package `) {
+			return strings.TrimSpace(strings.TrimPrefix(line, `package `))
+		}
+	}
+	dir, _ := filepath.Abs(filepath.Dir(path))
+	return filepath.Base(dir)
+}
+
+// parseRegions returns the user code of the regions of a generated file.
+func parseRegions(src []byte) map[string]string {
+	regions := make(map[string]string)
+	var (
+		name string
+		code strings.Builder
+		in   bool
+	)
+	s := bufio.NewScanner(bytes.NewReader(src))
+	for s.Scan() {
+		line := s.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case !in && strings.HasPrefix(trimmed, regionBegin):
+			name, in = strings.TrimPrefix(trimmed, regionBegin), true
+			code.Reset()
+		case in && trimmed == regionEnd+name:
+			regions[name], in = code.String(), false
+		case in:
+			code.WriteString(line + "\n")
+		}
+	}
+	return regions
+}
+
+// mergeRegions writes the user code into the regions of the generated source.
+// The regions of tables that are no longer generated are kept at its end.
+func mergeRegions(src string, regions map[string]string) string {
+	var b strings.Builder
+	lines := strings.SplitAfter(src, "\n")
+	for _, line := range lines {
+		b.WriteString(line)
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, regionBegin) {
+			continue
+		}
+		name := strings.TrimPrefix(trimmed, regionBegin)
+		b.WriteString(regions[name])
+		delete(regions, name)
+	}
+	names := make([]string, 0, len(regions))
+	for name, code := range regions {
+		if strings.TrimSpace(code) != `` {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString("\n" + regionBegin + name + "\n" + regions[name] + regionEnd + name + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateInPlace(t *testing.T) {
+	out := filepath.Join(t.TempDir(), `model`, `model.go`)
+	ddl := []string{`ddl`, `testdata/users.sql`, `-o`, out}
+	if output, err := execute(t, ddl...); err != nil {
+		t.Fatalf("ddl: %v\n%s", err, output)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := "// leopards:begin Users\nfunc (u *Users) Adult() bool { return u.Age >= 18 }\n\n"
+	edited := strings.Replace(string(b), "// leopards:begin Users\n", code, 1)
+	if err := os.WriteFile(out, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := execute(t, ddl...); err != nil {
+		t.Fatalf("ddl: %v\n%s", err, output)
+	}
+	if b, _ = os.ReadFile(out); string(b) != edited {
+		t.Errorf("generated again:\n%s\nwant the file with its user code:\n%s", b, edited)
+	}
+	if output, err := execute(t, append(ddl, `--check`)...); err != nil {
+		t.Errorf("--check of an up-to-date file: %v\n%s", err, output)
+	}
+
+	stale := strings.Replace(edited, `UsersColumnAge `, `UsersColumnAge2`, 1)
+	if err := os.WriteFile(out, []byte(stale), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output, err := execute(t, append(ddl, `--check`)...)
+	if err == nil || !strings.Contains(err.Error(), `out of date: `+out) {
+		t.Errorf("--check of a stale file: %v, want an out of date error", err)
+	}
+	if strings.Contains(output, `Usage:`) {
+		t.Errorf("--check printed the usage:\n%s", output)
+	}
+	if b, _ = os.ReadFile(out); string(b) != stale {
+		t.Error("--check rewrote the stale file")
+	}
+}
+
+func TestGenerateCheckOut(t *testing.T) {
+	output, err := execute(t, `ddl`, `testdata/users.sql`, `--check`)
+	if err == nil || err.Error() != `--out: output file not specified` {
+		t.Errorf("--check without --out: %v, want a missing --out error", err)
+	}
+	if strings.Contains(output, `Usage:`) {
+		t.Errorf("--check printed the usage:\n%s", output)
+	}
+}
+
+func TestGenerateTemplates(t *testing.T) {
+	out := filepath.Join(t.TempDir(), `dao`)
+	if output, err := execute(t, `ddl`, `testdata/users.sql`, `-o`, out, `--template`, `testdata/templates`); err != nil {
+		t.Fatalf("ddl: %v\n%s", err, output)
+	}
+	files, err := filepath.Glob(filepath.Join(out, `*`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("generated %v, want dao.go and model.proto", files)
+	}
+	for _, name := range []string{`dao.go`, `model.proto`} {
+		b, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden(t, `templates_`+name, b)
+	}
+}
+
+func TestGenerateSplitOrphans(t *testing.T) {
+	out := filepath.Join(t.TempDir(), `model`)
+	if output, err := execute(t, `ddl`, `testdata/shop.sql`, `-o`, out, `--split`); err != nil {
+		t.Fatalf("ddl: %v\n%s", err, output)
+	}
+	// The orders table holds user code, and ext.go is not generated.
+	orders := filepath.Join(out, `orders.go`)
+	b, err := os.ReadFile(orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(b), "// leopards:begin Orders\n", "// leopards:begin Orders\nfunc (o *Orders) Paid() bool { return true }\n\n", 1)
+	if err := os.WriteFile(orders, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ext := filepath.Join(out, `ext.go`)
+	if err := os.WriteFile(ext, []byte("package model\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The tables of shop.sql but users are dropped.
+	ddl := []string{`ddl`, `testdata/users.sql`, `-o`, out, `--split`}
+	_, err = execute(t, append(ddl, `--check`)...)
+	if err == nil || !strings.Contains(err.Error(), `orphan files, no longer generated: `+filepath.Join(out, `order_products.go`)) {
+		t.Errorf("--check of dropped tables: %v, want an orphan files error", err)
+	}
+	output, err := execute(t, ddl...)
+	if err != nil {
+		t.Fatalf("ddl: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, `warning: `+orders+` is no longer generated, kept for the user code of its regions`) {
+		t.Errorf("output:\n%s\nwant a warning for the user code of orders.go", output)
+	}
+	files, err := filepath.Glob(filepath.Join(out, `*.go`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{ext, orders, filepath.Join(out, `users.go`)}
+	if strings.Join(files, ` `) != strings.Join(want, ` `) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	if b, _ = os.ReadFile(orders); string(b) != edited {
+		t.Error("the orphan file with user code was modified")
+	}
+}
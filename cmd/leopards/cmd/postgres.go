@@ -1,28 +1,77 @@
 package cmd
 
 import (
-	bytes2 "bytes"
-	"errors"
-	"os"
+	"context"
+	"fmt"
 	"path/filepath"
 	"strings"
-	"text/template"
-	"time"
 
 	"github.com/liqiongfan/leopards"
 	"github.com/spf13/cobra"
 )
 
 type PgTable struct {
-	TableCatalog    string  `json:"table_catalog"`
-	TableSchema     string  `json:"table_schema"`
-	TableName       string  `json:"table_name"`
-	TableType       string  `json:"table_type"`
-	Comment         *string `json:"description"`
-	Columns         []PgColumn
-	MaxColumnLength int
-	MaxTypeLength   int
-	MaxNameLength   int
+	TableCatalog string  `json:"table_catalog"`
+	TableSchema  string  `json:"table_schema"`
+	TableName    string  `json:"table_name"`
+	TableType    string  `json:"table_type"`
+	Comment      *string `json:"description"`
+	Columns      []PgColumn
+	Indexes      []PgIndex
+	ForeignKeys  []PgForeignKey
+}
+
+// PgIndex is an indexed column of pg_index.
+type PgIndex struct {
+	IndexName  string `json:"index_name"`
+	IsUnique   bool   `json:"is_unique"`
+	IsPrimary  bool   `json:"is_primary"`
+	ColumnName string `json:"column_name"`
+}
+
+// PgForeignKey is a column of a foreign key constraint of pg_constraint.
+type PgForeignKey struct {
+	ConstraintName string `json:"constraint_name"`
+	ColumnName     string `json:"column_name"`
+	RefSchema      string `json:"ref_schema"`
+	RefTable       string `json:"ref_table"`
+	RefColumn      string `json:"ref_column"`
+	UpdateRule     string `json:"update_rule"`
+	DeleteRule     string `json:"delete_rule"`
+}
+
+// pgIndexes selects the indexed columns of a table in index order.
+const pgIndexes = `SELECT i.relname AS index_name, ix.indisunique AS is_unique, ix.indisprimary AS is_primary, a.attname AS column_name
+FROM pg_index ix
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE n.nspname = $1 AND t.relname = $2
+ORDER BY i.relname, k.ord`
+
+// pgForeignKeys selects the columns of the foreign keys of a table in key order.
+const pgForeignKeys = `SELECT c.conname AS constraint_name, a.attname AS column_name, rn.nspname AS ref_schema, rt.relname AS ref_table, ra.attname AS ref_column,
+	c.confupdtype::text AS update_rule, c.confdeltype::text AS delete_rule
+FROM pg_constraint c
+JOIN pg_class t ON t.oid = c.conrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN pg_class rt ON rt.oid = c.confrelid
+JOIN pg_namespace rn ON rn.oid = rt.relnamespace
+JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refnum, ord) ON true
+JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refnum
+WHERE c.contype = 'f' AND n.nspname = $1 AND t.relname = $2
+ORDER BY c.conname, k.ord`
+
+// pgRules are the referential actions of the pg_constraint action codes.
+var pgRules = map[string]string{
+	`a`: `NO ACTION`,
+	`r`: `RESTRICT`,
+	`c`: `CASCADE`,
+	`n`: `SET NULL`,
+	`d`: `SET DEFAULT`,
 }
 
 type PgColumn struct {
@@ -33,6 +82,7 @@ type PgColumn struct {
 	ColumnDefault          *string `json:"column_default"`
 	IsNullAble             string  `json:"is_nullable"`
 	DataType               string  `json:"data_type"`
+	ColumnType             string  `json:"column_type"` // format_type of the column, e.g. character varying(64), text[].
 	CharacterMaximumLength *int    `json:"character_maximum_length"`
 	CharacterOctetLength   *int    `json:"character_octet_length"`
 	NumericPrecision       *int    `json:"numeric_precision"`
@@ -40,37 +90,57 @@ type PgColumn struct {
 	NumericScale           *int    `json:"numeric_scale"`
 	UdtName                string  `json:"udt_name"`
 	UdtCatalog             string  `json:"udt_catalog"`
+	UdtSchema              string  `json:"udt_schema"`
+	DomainName             *string `json:"domain_name"`
 	IsUpdatable            string  `json:"is_updatable"`
+	IsIdentity             string  `json:"is_identity"`
 	Comment                *string `json:"description"`
 	CamelName              *string
+	Enum                   []string // labels of the enum type, or of the element type of arrays.
 }
 
-const TemplatePGStruct = `
-//
-// created by leopards at: {{ .Time }}
-// 
+// PgEnum is a label of an enum type of pg_enum.
+type PgEnum struct {
+	TypeSchema string `json:"type_schema"`
+	TypeName   string `json:"type_name"`
+	Label      string `json:"label"`
+}
 
-{{ range $key, $value := .Data }}
+// pgEnums selects the labels of the enum types in sort order.
+const pgEnums = `SELECT n.nspname AS type_schema, t.typname AS type_name, e.enumlabel AS label
+FROM pg_enum e
+JOIN pg_type t ON t.oid = e.enumtypid
+JOIN pg_namespace n ON n.oid = t.typnamespace
+ORDER BY n.nspname, t.typname, e.enumsortorder`
 
-// {{ camel $value.TableName }}Table {{ emit $value.Comment }}
-const {{ camel $value.TableName }}Table = "{{ $value.TableName }}"
+// TemplatePGStruct is the default template of the PostgreSQL tables.
+//
+// Deprecated: the MySQL and PostgreSQL tables share TemplateStruct.
+const TemplatePGStruct = TemplateStruct
 
-// {{ camel $value.TableName }} {{ emit $value.Comment }}
-type {{ camel $value.TableName }} struct { 
-{{ range .Columns }}    {{ camel .CamelName }}{{ pad (camel .ColumnName) $value.MaxColumnLength }} {{ type .DataType .IsNullAble .UdtName }}{{ pad (type .DataType .IsNullAble .UdtName) $value.MaxTypeLength }}  {{ tag .ColumnName .Comment $value.MaxNameLength }}
-{{ end -}} 
+// PGType returns the Go type of a PostgreSQL column. Unknown types are mapped to string.
+func PGType(dataType, isNullable, udtName string) string {
+	res, ok := pgType(udtName)
+	if !ok {
+		res = `string`
+	}
+	if isNullable == `YES` {
+		res = `*` + res
+	}
+	return res
 }
-{{ end }}
-`
 
-func PGType(dataType, isNullable, udtName string) (res string) {
+// pgType returns the Go type of the values of a PostgreSQL column,
+// and false if the type is unknown.
+func pgType(udtName string) (res string, ok bool) {
+	ok = true
 	switch udtName {
 	case `bigserial`:
 		res = `uint64`
 	case `bit`:
 		res = `[]byte`
 	case `bool`:
-		res = `byte`
+		res = `bool`
 	case `box`:
 		fallthrough
 	case `bytea`:
@@ -151,57 +221,126 @@ func PGType(dataType, isNullable, udtName string) (res string) {
 		res = `[]byte`
 	case `varchar`:
 		fallthrough
-	case `xml`:
+	case `bpchar`:
 		fallthrough
-	default:
+	case `citext`:
+		fallthrough
+	case `name`:
+		fallthrough
+	case `xml`:
 		res = `string`
+	case `_int2`, `_int4`, `_int8`:
+		res = `pq.Int64Array`
+	case `_float4`, `_float8`:
+		res = `pq.Float64Array`
+	case `_bool`:
+		res = `pq.BoolArray`
+	case `_bytea`:
+		res = `pq.ByteaArray`
+	case `_text`, `_varchar`, `_bpchar`, `_citext`, `_uuid`, `_numeric`:
+		res = `pq.StringArray`
+	default:
+		ok = false
 	}
-
-	switch isNullable {
-	case `YES`:
-		res = `*` + res
-	case `NO`:
-	}
-
 	return
 }
 
+// pgGenerate generates the code of the tables. The tables of several schemas
+// (--schema a,b or *) are generated into a package per schema, named after
+// the schema: the --out file is generated into the <schema> directory next
+// to it, and with --split or --template, into the <schema> directory of the
+// --out directory.
 func pgGenerate(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	info, database, err := connection(cmd, cfg, leopards.Postgres)
+	if err != nil {
+		return err
+	}
+	database, tableName, err := databaseArgs(args, database)
+	if err != nil {
+		return err
+	}
+	schemas, _ := cmd.Flags().GetString(`schema`)
+	tables, err := pgIntrospect(cmd.Context(), info, database, schemas, tableName)
+	if err != nil {
+		return err
+	}
+	if schemas != `*` && !strings.Contains(schemas, `,`) {
+		return render(cmd, pgSchema(cfg, database, tables))
+	}
+
+	output, _ := cmd.Flags().GetString(`out`)
+	split, _ := cmd.Flags().GetBool(`split`)
+	dir, _ := cmd.Flags().GetString(`template`)
+	var names []string
+	bySchema := make(map[string][]PgTable)
+	for _, t := range tables {
+		if _, ok := bySchema[t.TableSchema]; !ok {
+			names = append(names, t.TableSchema)
+		}
+		bySchema[t.TableSchema] = append(bySchema[t.TableSchema], t)
+	}
+	for _, name := range names {
+		out := filepath.Join(filepath.Dir(output), name, filepath.Base(output))
+		if split || dir != `` {
+			out = filepath.Join(output, name)
+		}
+		if err := renderTo(cmd, pgSchema(cfg, database, bySchema[name]), out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	info := getPGInfo(cmd)
+// pgIntrospect reads the tables of the schemas of the database from
+// information_schema and the system catalogs. The schemas are separated by
+// commas, or * for all the schemas except the system ones. The table name
+// is * for all the tables of the schemas.
+func pgIntrospect(ctx context.Context, info *Info, database, schemas, tableName string) ([]PgTable, error) {
 	orm, err := leopards.OpenOptions{
 		User:     info.User,
 		Password: info.Password,
 		Host:     info.Host,
 		Port:     info.Port,
-		Database: args[0],
+		Database: database,
 		Debug:    false,
 		Dialect:  leopards.Postgres,
 		Charset:  info.Charset,
+		Params:   info.Params,
 	}.Open()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	schema, _ := cmd.Flags().GetString(`schema`)
-
-	database := args[0]
-	tableName := args[1]
-
-	tables := make([]PgTable, 0, 20)
 	t1 := orm.Table(`tables`).Schema(`information_schema`).As(`tb`)
 	t2 := orm.Table(`pg_class`)
 	t3 := orm.Table(`pg_description`).As(`d`)
+	t4 := orm.Table(`pg_namespace`).As(`n`)
 
 	predicates := []*leopards.Predicate{
 		leopards.EQ(t1.C(`table_catalog`), database),
-		leopards.EQ(t1.C(`table_schema`), schema),
+	}
+	if schemas == `*` {
+		predicates = append(predicates,
+			leopards.NotIn(t1.C(`table_schema`), `pg_catalog`, `information_schema`),
+			leopards.Not(leopards.HasPrefix(t1.C(`table_schema`), `pg_`)),
+		)
+	} else {
+		ins := make([]any, 0, 4)
+		for _, name := range strings.Split(schemas, `,`) {
+			ins = append(ins, strings.TrimSpace(name))
+		}
+		predicates = append(predicates, leopards.In(t1.C(`table_schema`), ins...))
 	}
 
 	if tableName != `*` {
 		predicates = append(predicates, leopards.EQ(t1.C(`table_name`), tableName))
 	}
 
+	tables := make([]PgTable, 0, 20)
 	err = orm.Query().
 		Select(
 			t1.C(`table_catalog`),
@@ -211,7 +350,8 @@ func pgGenerate(cmd *cobra.Command, args []string) error {
 			t3.C(`description`),
 		).
 		FromTable(t1).
-		Join(t2.As(`c`)).On(t1.C(`table_name`), t2.C(`relname`)).
+		Join(t4).On(t1.C(`table_schema`), t4.C(`nspname`)).
+		Join(t2.As(`c`)).On(t1.C(`table_name`), t2.C(`relname`)).On(t2.C(`relnamespace`), t4.C(`oid`)).
 		LeftJoin(t3).
 		On(t3.C(`objoid`), t2.C(`oid`)).OnP(leopards.EQ(t3.C(`objsubid`), 0)).
 		Where(
@@ -219,16 +359,26 @@ func pgGenerate(cmd *cobra.Command, args []string) error {
 				predicates...,
 			),
 		).
-		Scan(cmd.Context(), &tables)
+		OrderBy(t1.C(`table_schema`), t1.C(`table_name`)).
+		Scan(ctx, &tables)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var labels []PgEnum
+	if err := orm.Raw(ctx, pgEnums).Scan(&labels); err != nil {
+		return nil, err
+	}
+	enums := make(map[string][]string)
+	for _, l := range labels {
+		enums[l.TypeSchema+`.`+l.TypeName] = append(enums[l.TypeSchema+`.`+l.TypeName], l.Label)
 	}
 
 	x1 := orm.Table(`columns`).Schema(`information_schema`).As(`col`)
 	x2 := orm.Table(`pg_class`).As(`c`)
 	x3 := orm.Table(`pg_description`).As(`d`)
-
-	needImportTime := false
+	x4 := orm.Table(`pg_namespace`).As(`n`)
+	x5 := orm.Table(`pg_attribute`).As(`a`)
 
 	for i, table := range tables {
 		columns := make([]PgColumn, 0, 20)
@@ -248,129 +398,116 @@ func pgGenerate(cmd *cobra.Command, args []string) error {
 				x1.C(`numeric_scale`),
 				x1.C(`udt_name`),
 				x1.C(`udt_catalog`),
+				x1.C(`udt_schema`),
+				x1.C(`domain_name`),
 				x1.C(`is_updatable`),
+				x1.C(`is_identity`),
 				x3.C(`description`),
-			).FromTable(x1).Join(x2).On(x1.C(`table_name`), x2.C(`relname`)).
+			).
+			AppendSelectExprAs(leopards.Raw(fmt.Sprintf(`format_type(%s, %s)`, x5.C(`atttypid`), x5.C(`atttypmod`))), `column_type`).
+			FromTable(x1).
+			Join(x4).On(x1.C(`table_schema`), x4.C(`nspname`)).
+			Join(x2).On(x1.C(`table_name`), x2.C(`relname`)).On(x2.C(`relnamespace`), x4.C(`oid`)).
+			Join(x5).On(x5.C(`attrelid`), x2.C(`oid`)).On(x5.C(`attname`), x1.C(`column_name`)).
 			LeftJoin(x3).On(
 			x3.C(`objoid`),
 			x2.C(`oid`),
 		).On(x3.C(`objsubid`), x1.C(`ordinal_position`)).
-			Where(leopards.EQ(x1.C(`table_schema`), schema)).
+			Where(leopards.EQ(x1.C(`table_schema`), table.TableSchema)).
 			Where(leopards.EQ(x1.C(`table_name`), table.TableName)).
 			OrderBy(x1.C(`table_name`), x1.C(`ordinal_position`)).
-			Scan(cmd.Context(), &columns)
+			Scan(ctx, &columns)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		for j, c := range columns {
+			// Enum types, and the element types of the arrays of enums.
+			columns[j].Enum = enums[c.UdtSchema+`.`+strings.TrimPrefix(c.UdtName, `_`)]
 		}
 
-		flags := make(map[string]struct{}, len(columns))
-		for j, column := range columns {
-			camelName := camel(column.ColumnName)
-			column.CamelName = column.ColumnName
-			columns[j].CamelName = column.ColumnName
+		tables[i].Columns = columns
 
-			if _, ok := flags[camelName]; ok {
-				tName := snake(camelName)
-				column.ColumnName = &tName
-				columns[j].CamelName = &tName
-			} else {
-				flags[camelName] = struct{}{}
-			}
+		err = orm.Raw(ctx, pgIndexes, table.TableSchema, table.TableName).Scan(&tables[i].Indexes)
+		if err != nil {
+			return nil, err
+		}
+		err = orm.Raw(ctx, pgForeignKeys, table.TableSchema, table.TableName).Scan(&tables[i].ForeignKeys)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
 
-			if strings.Contains(PGType(column.DataType, column.IsNullAble, column.UdtName), `time.Time`) {
-				needImportTime = true
+// pgSchema converts the PostgreSQL tables to the data model of the templates.
+func pgSchema(cfg *Config, database string, tables []PgTable) *Schema {
+	s := &Schema{Dialect: leopards.Postgres, Database: database}
+	for _, table := range tables {
+		t := &TableInfo{Schema: table.TableSchema, Name: table.TableName}
+		if table.Comment != nil {
+			t.Comment = *table.Comment
+		}
+		for _, column := range table.Columns {
+			c := &ColumnInfo{
+				Name:       *column.ColumnName,
+				DataType:   column.UdtName,
+				ColumnType: column.ColumnType,
+				Nullable:   column.IsNullAble == `YES`,
+				Default:    column.ColumnDefault,
 			}
-			if length := len(camel(column.CamelName)); length > tables[i].MaxColumnLength {
-				tables[i].MaxColumnLength = length
+			if column.Comment != nil {
+				c.Comment = *column.Comment
 			}
-			if length := len(PGType(column.DataType, column.IsNullAble, column.UdtName)); length > tables[i].MaxTypeLength {
-				tables[i].MaxTypeLength = length
+			if column.CharacterMaximumLength != nil {
+				c.Size = int64(*column.CharacterMaximumLength)
 			}
-			if length := len(*column.ColumnName); length > tables[i].MaxNameLength {
-				tables[i].MaxNameLength = length
+			if column.DomainName != nil {
+				c.Domain = *column.DomainName
 			}
-
-		}
-
-		tables[i].Columns = columns
-
-	}
-
-	output, err := cmd.Flags().GetString(`out`)
-	if err != nil {
-		return err
-	}
-
-	if output == `` {
-		return trace(errors.New(`--out: output file not specified`))
-	}
-
-	t := template.New(`template`)
-
-	t = t.Funcs(map[string]any{
-		`camel`: camel,
-		`type`:  PGType,
-		`tag`:   tagPtr,
-		`pad`:   pad,
-		`enum`:  enum,
-		`emit`: func(s *string) string {
-			if s != nil {
-				return *s
+			array := strings.HasPrefix(column.UdtName, `_`)
+			if len(column.Enum) > 0 && !array {
+				c.Enum = column.Enum
+				c.EnumType = camel(&column.UdtName) + `Type`
+				c.pgEnum = column.UdtSchema + `.` + column.UdtName
 			}
-			return ``
-		},
-	})
-	t, err = t.Parse(TemplatePGStruct)
-	if err != nil {
-		return trace(err)
-	}
-
-	bytes := bytes2.NewBuffer(nil)
-
-	err = t.Execute(bytes, map[string]any{
-		`Time`: time.Now().Format(`2006-01-02 15:04:05`),
-		`Data`: tables,
-	})
-	if err != nil {
-		return err
-	}
-
-	f, err := os.OpenFile(output, os.O_CREATE|os.O_RDWR|os.O_APPEND, os.ModePerm)
-	if err != nil {
-		return err
-	}
-	fi, err := f.Stat()
-	if err != nil {
-		return err
-	}
-
-	if fi.Size() == 0 {
-		packageName := ``
-		if filepath.Dir(output) == `.` {
-			wd, _ := os.Getwd()
-			packageName = filepath.Base(wd)
-		} else {
-			packageName = filepath.Base(filepath.Dir(output))
-			_ = os.MkdirAll(filepath.Dir(output), os.ModePerm)
+			c.AutoIncrement = column.IsIdentity == `YES` || c.Default != nil && strings.HasPrefix(*c.Default, `nextval(`)
+			cfg.columnTypes(t.Name, c, func(c *ColumnInfo) (string, bool) {
+				switch {
+				case len(column.Enum) > 0 && array:
+					return `pq.StringArray`, true
+				case len(column.Enum) > 0:
+					return `string`, true
+				}
+				return pgType(c.DataType)
+			})
+			t.Columns = append(t.Columns, c)
 		}
-
-		_, _ = f.WriteString(`package ` + packageName)
-		if needImportTime {
-			_, _ = f.WriteString("\n\nimport \"time\"")
+		for _, idx := range table.Indexes {
+			t.addIndex(idx.IndexName, idx.IsUnique, idx.IsPrimary, idx.ColumnName)
+		}
+		for _, fk := range table.ForeignKeys {
+			ref := fk.RefTable
+			if fk.RefSchema != `` && fk.RefSchema != table.TableSchema {
+				// Tables of other schemas are not generated with the table.
+				ref = fk.RefSchema + `.` + fk.RefTable
+			}
+			t.addForeignKey(fk.ConstraintName, fk.ColumnName, ref, fk.RefColumn, pgRules[fk.UpdateRule], pgRules[fk.DeleteRule])
 		}
+		t.keys()
+		t.goNames()
+		cfg.tags(t)
+		s.Tables = append(s.Tables, t)
 	}
-
-	defer f.Close()
-	_, _ = f.WriteString(bytes.String())
-
-	return nil
+	s.edges()
+	s.enums()
+	return s
 }
 
 var postgresCMD = &cobra.Command{
-	Use:   `postgres database table [-h]`,
+	Use:   `postgres [database] table [-h]`,
 	Short: `A PostgreSQL schema generate tool for leopards`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) != 2 {
+		if len(args) < 1 || len(args) > 2 {
 			return cmd.Help()
 		}
 		return pgGenerate(cmd, args)
@@ -382,6 +519,8 @@ func init() {
 	postgresCMD.Flags().StringP(`password`, `P`, ``, `PostgrsSQL database password`)
 	postgresCMD.Flags().StringP(`host`, `H`, ``, `PostgreSQL database host`)
 	postgresCMD.Flags().StringP(`port`, `p`, `5432`, `PostgreSQL database port`)
-	postgresCMD.Flags().StringP(`schema`, `s`, `public`, `PostgreSQL schema, default public`)
+	postgresCMD.Flags().StringP(`schema`, `s`, `public`, `PostgreSQL schemas separated by commas, or * for all, default public`)
 	postgresCMD.Flags().StringP(`out`, `o`, ``, `output path`)
+	connectionFlags(postgresCMD)
+	outputFlags(postgresCMD)
 }
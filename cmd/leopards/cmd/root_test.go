@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/liqiongfan/leopards"
+	"github.com/spf13/cobra"
+)
+
+func TestConnection(t *testing.T) {
+	c := &cobra.Command{}
+	connectionFlags(c)
+	c.Flags().String(`host`, ``, ``)
+	if err := c.ParseFlags([]string{`--dsn`, `postgres://app:secret@pg/shop?sslmode=disable`, `--host`, `localhost`}); err != nil {
+		t.Fatal(err)
+	}
+	info, database, err := connection(c, &Config{}, leopards.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Info{User: `app`, Password: `secret`, Host: `localhost`, Port: `5432`, Params: map[string]string{`sslmode`: `disable`}}
+	if !reflect.DeepEqual(info, want) || database != `shop` {
+		t.Errorf("connection = %+v, %q, want %+v, shop", info, database, want)
+	}
+	if _, _, err := connection(c, &Config{}, leopards.MySQL); err == nil {
+		t.Error("connection of a postgres DSN for mysql, want an error")
+	}
+
+	c = &cobra.Command{}
+	connectionFlags(c)
+	if err := c.ParseFlags([]string{`--dsn`, `mysql8://root@db/shop`}); err != nil {
+		t.Fatal(err)
+	}
+	info, database, err = connection(c, &Config{}, leopards.MySQL)
+	if err != nil || info.Host != `db` || database != `shop` {
+		t.Errorf("connection of a mysql8 DSN = %+v, %q, %v, want host db and database shop", info, database, err)
+	}
+}
@@ -0,0 +1,572 @@
+package cmd
+
+import (
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Schema is the data model of the generator templates. It is a dialect-neutral
+// description of the introspected tables, shared by the MySQL and PostgreSQL
+// commands.
+type Schema struct {
+	Dialect  string       // mysql or postgres.
+	Database string       // database name.
+	Package  string       // package name of the generated file.
+	Tables   []*TableInfo // tables of the generated file.
+	Table    *TableInfo   // table of the generated file with --split, nil otherwise.
+	Enums    []*EnumInfo  // enum types declared in the generated file.
+	Repo     bool         // generate the repositories of the tables (--repo).
+}
+
+// EnumInfo describes the Go type of the values of enum columns: a MySQL enum
+// or set column, or a PostgreSQL enum type shared by its columns.
+type EnumInfo struct {
+	Name   string     // Go type, e.g. StatusType.
+	Prefix string     // prefix of the constants, e.g. Status for StatusActive.
+	Values []string   // values in declaration order.
+	Table  *TableInfo // first table with a column of the type.
+}
+
+// TableInfo describes a table.
+type TableInfo struct {
+	Schema      string            // schema (PostgreSQL) or database (MySQL) of the table.
+	Name        string            // table name.
+	GoName      string            // Go name, e.g. UserInfo.
+	Comment     string            // table comment.
+	Columns     []*ColumnInfo     // columns in ordinal order.
+	PrimaryKey  []*ColumnInfo     // primary key columns.
+	Indexes     []*IndexInfo      // indexes, including unique indexes.
+	ForeignKeys []*ForeignKeyInfo // foreign keys.
+	Edges       []*EdgeInfo       // relationships with the other tables, derived from the foreign keys.
+}
+
+// ColumnInfo describes a column.
+type ColumnInfo struct {
+	Name          string   // column name.
+	GoName        string   // Go name of the struct field, e.g. UserId.
+	Comment       string   // column comment.
+	DataType      string   // data type, e.g. varchar, int4.
+	ColumnType    string   // full column type, e.g. varchar(255), int unsigned, text[].
+	GoType        string   // Go type of the struct field, e.g. *int64.
+	FieldType     string   // Go type of the values, e.g. int64 or the enum type.
+	Nullable      bool     // NULL values are allowed.
+	PrimaryKey    bool     // part of the primary key.
+	Unique        bool     // unique column.
+	AutoIncrement bool     // filled by the database (auto_increment, serial, identity).
+	Default       *string  // default value expression, if any.
+	Size          int64    // maximum length of character columns, or 0.
+	Domain        string   // domain (PostgreSQL) of the column type, if any.
+	Enum          []string // values of enum and set columns, and of PostgreSQL enum types.
+	EnumType      string   // Go type of enum values, e.g. StatusType.
+	Tag           string   // struct tag of the field, e.g. json:"user_id".
+	Imports       []string // import paths of the packages of GoType and FieldType.
+
+	pgEnum string // PostgreSQL enum type of the column (schema.name), empty for enum and set columns.
+}
+
+// IndexInfo describes an index.
+type IndexInfo struct {
+	Name    string   // index name.
+	Unique  bool     // unique index.
+	Primary bool     // index of the primary key.
+	Columns []string // indexed columns.
+}
+
+// ForeignKeyInfo describes a foreign key.
+type ForeignKeyInfo struct {
+	Name       string   // constraint name.
+	Columns    []string // columns of the table.
+	RefTable   string   // referenced table.
+	RefColumns []string // referenced columns.
+	OnUpdate   string   // ON UPDATE action.
+	OnDelete   string   // ON DELETE action.
+}
+
+// EdgeInfo describes a relationship of a table with another table (or itself).
+// The rows of the related table are selected by their RefColumns, equal to the
+// Columns of the table, or through a join table for many-to-many edges:
+//
+//	belongs-to:   order.user_id => user.id         (QueryUser)
+//	has-many:     user.id       => order.user_id   (QueryOrders)
+//	many-to-many: user.id       => user_group.user_id, user_group.group_id => group.id (QueryGroups)
+type EdgeInfo struct {
+	Name              string   // Go name of the edge, e.g. Orders or User.
+	Unique            bool     // the edge has at most one row.
+	RefTable          string   // related table.
+	RefGoName         string   // Go name of the related table.
+	Columns           []string // columns of the table.
+	Fields            []string // Go names of the fields of Columns.
+	RefColumns        []string // columns of the related table.
+	Through           string   // join table of many-to-many edges.
+	ThroughGoName     string   // Go name of the join table.
+	ThroughColumns    []string // columns of the join table referencing Columns.
+	ThroughRefColumns []string // columns of the join table referencing RefColumns.
+}
+
+// Column returns the column with the given name, or nil.
+func (t *TableInfo) Column(name string) *ColumnInfo {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// addIndex adds the column to the index with the given name.
+func (t *TableInfo) addIndex(name string, unique, primary bool, column string) {
+	for _, idx := range t.Indexes {
+		if idx.Name == name {
+			idx.Columns = append(idx.Columns, column)
+			return
+		}
+	}
+	t.Indexes = append(t.Indexes, &IndexInfo{Name: name, Unique: unique || primary, Primary: primary, Columns: []string{column}})
+}
+
+// addForeignKey adds the column and its referenced column to the foreign key with the given name.
+func (t *TableInfo) addForeignKey(name, column, refTable, refColumn, onUpdate, onDelete string) {
+	for _, fk := range t.ForeignKeys {
+		if fk.Name == name {
+			fk.Columns = append(fk.Columns, column)
+			fk.RefColumns = append(fk.RefColumns, refColumn)
+			return
+		}
+	}
+	t.ForeignKeys = append(t.ForeignKeys, &ForeignKeyInfo{
+		Name:       name,
+		Columns:    []string{column},
+		RefTable:   refTable,
+		RefColumns: []string{refColumn},
+		OnUpdate:   onUpdate,
+		OnDelete:   onDelete,
+	})
+}
+
+// keys sets the primary key and the unique columns of the table from its indexes.
+func (t *TableInfo) keys() {
+	for _, idx := range t.Indexes {
+		switch {
+		case idx.Primary:
+			t.PrimaryKey = nil
+			for _, name := range idx.Columns {
+				if c := t.Column(name); c != nil {
+					c.PrimaryKey = true
+					t.PrimaryKey = append(t.PrimaryKey, c)
+				}
+			}
+		case idx.Unique && len(idx.Columns) == 1:
+			if c := t.Column(idx.Columns[0]); c != nil {
+				c.Unique = true
+			}
+		}
+	}
+}
+
+// SecondaryIndexes returns the indexes of the table except the primary key index.
+func (t *TableInfo) SecondaryIndexes() []*IndexInfo {
+	var indexes []*IndexInfo
+	for _, idx := range t.Indexes {
+		if !idx.Primary {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes
+}
+
+// HasEnum reports if the table has enum or set columns.
+func (t *TableInfo) HasEnum() bool {
+	for _, c := range t.Columns {
+		if len(c.Enum) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Imports returns the packages imported by the Go types of the columns of the tables.
+func (s *Schema) Imports() []string {
+	set := make(map[string]struct{})
+	for _, t := range s.Tables {
+		for _, c := range t.Columns {
+			for _, pkg := range c.Imports {
+				set[pkg] = struct{}{}
+			}
+		}
+	}
+	imports := make([]string, 0, len(set))
+	for pkg := range set {
+		imports = append(imports, pkg)
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// typePackages are the import paths of the packages of the qualified Go types.
+var typePackages = map[string]string{
+	`time`: `time`,
+	`sql`:  `database/sql`,
+	`json`: `encoding/json`,
+	`pq`:   `github.com/lib/pq`,
+}
+
+// typePackage returns the import path of the package of a qualified Go type.
+func typePackage(typ string) string {
+	typ = strings.TrimLeft(typ, `*[]`)
+	i := strings.LastIndex(typ, `.`)
+	if i == -1 {
+		return ``
+	}
+	if path, ok := typePackages[typ[:i]]; ok {
+		return path
+	}
+	return ``
+}
+
+// goNames sets the Go names of the table and its columns. Columns whose
+// names collide once camel-cased are renamed with their snake-cased name.
+func (t *TableInfo) goNames() {
+	t.GoName = camel(&t.Name)
+	flags := make(map[string]struct{}, len(t.Columns))
+	for _, c := range t.Columns {
+		c.GoName = camel(&c.Name)
+		if _, ok := flags[c.GoName]; ok {
+			c.GoName = snake(c.GoName)
+		} else {
+			flags[c.GoName] = struct{}{}
+		}
+		if len(c.Enum) > 0 {
+			if c.EnumType == `` {
+				c.EnumType = camel(&c.Name) + `Type`
+			}
+			c.FieldType = c.EnumType
+		}
+	}
+}
+
+// enums sets the enum types of the schema from the enum columns of its
+// tables. The columns of a PostgreSQL enum type share its Go type, and so do
+// the enum and set columns with the same name and values. Enum types whose
+// Go names collide are prefixed with their schema (PostgreSQL) or the table
+// of their first column, e.g. BillingStatusType or OrdersStatusType.
+func (s *Schema) enums() {
+	type enum struct {
+		info    *EnumInfo
+		schema  string
+		columns []*ColumnInfo
+	}
+	var (
+		enums []*enum
+		keys  = make(map[string]*enum)
+		names = make(map[string]int)
+	)
+	for _, t := range s.Tables {
+		for _, c := range t.Columns {
+			if len(c.Enum) == 0 {
+				continue
+			}
+			key := c.pgEnum
+			if key == `` {
+				key = c.EnumType + `(` + quotes(c.Enum) + `)`
+			}
+			e, ok := keys[key]
+			if !ok {
+				e = &enum{info: &EnumInfo{Name: c.EnumType, Values: c.Enum, Table: t}}
+				if i := strings.LastIndexByte(c.pgEnum, '.'); i != -1 {
+					e.schema = c.pgEnum[:i]
+				}
+				keys[key] = e
+				enums = append(enums, e)
+				names[e.info.Name]++
+			}
+			e.columns = append(e.columns, c)
+		}
+	}
+	for _, e := range enums {
+		switch {
+		case names[e.info.Name] == 1:
+		case e.schema != ``:
+			e.info.Name = camel(&e.schema) + e.info.Name
+		default:
+			e.info.Name = e.info.Table.GoName + e.info.Name
+		}
+		e.info.Prefix = strings.TrimSuffix(e.info.Name, `Type`)
+		for _, c := range e.columns {
+			c.EnumType, c.FieldType = e.info.Name, e.info.Name
+		}
+		s.Enums = append(s.Enums, e.info)
+	}
+}
+
+// table returns the table with the given name, or nil.
+func (s *Schema) table(name string) *TableInfo {
+	for _, t := range s.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// edges sets the edges of the tables from the foreign keys between them. Each
+// foreign key adds a belongs-to edge to its table, and a has-many (or has-one
+// if its columns are unique) edge to the referenced table. Join tables, whose
+// primary key (or a unique index) is made of the columns of their two foreign
+// keys, add many-to-many edges to the tables they join.
+func (s *Schema) edges() {
+	for _, t := range s.Tables {
+		for _, fk := range t.ForeignKeys {
+			ref := s.table(fk.RefTable)
+			if ref == nil {
+				continue
+			}
+			name := edgeName(fk.Columns, ref.GoName)
+			t.addEdge(name, &EdgeInfo{Unique: true, RefTable: ref.Name, RefGoName: ref.GoName, Columns: fk.Columns, RefColumns: fk.RefColumns}, fk.Columns)
+			inverse := &EdgeInfo{Unique: t.isUnique(fk.Columns), RefTable: t.Name, RefGoName: t.GoName, Columns: fk.RefColumns, RefColumns: fk.Columns}
+			name = plural(t.GoName)
+			if inverse.Unique {
+				name = singular(t.GoName)
+			}
+			if t.references(ref.Name) > 1 {
+				name += `By` + edgeName(fk.Columns, ref.GoName)
+			}
+			ref.addEdge(name, inverse, fk.Columns)
+		}
+		if len(t.ForeignKeys) != 2 || !t.isUnique(append(append([]string{}, t.ForeignKeys[0].Columns...), t.ForeignKeys[1].Columns...)) {
+			continue
+		}
+		for i, fk := range t.ForeignKeys {
+			from, to := s.table(fk.RefTable), s.table(t.ForeignKeys[1-i].RefTable)
+			if from == nil || to == nil {
+				continue
+			}
+			other := t.ForeignKeys[1-i]
+			from.addEdge(plural(edgeName(other.Columns, to.GoName)), &EdgeInfo{
+				RefTable:          to.Name,
+				RefGoName:         to.GoName,
+				Columns:           fk.RefColumns,
+				RefColumns:        other.RefColumns,
+				Through:           t.Name,
+				ThroughGoName:     t.GoName,
+				ThroughColumns:    fk.Columns,
+				ThroughRefColumns: other.Columns,
+			}, other.Columns)
+		}
+	}
+}
+
+// addEdge adds the edge with the given name, or with the name suffixed by
+// the foreign key columns if the table has an edge with the same name.
+func (t *TableInfo) addEdge(name string, e *EdgeInfo, columns []string) {
+	e.Name = name
+	for _, edge := range t.Edges {
+		if edge.Name == e.Name {
+			joined := strings.Join(columns, `_`)
+			e.Name = name + `By` + edgeName(columns, camel(&joined))
+			break
+		}
+	}
+	for _, c := range e.Columns {
+		if column := t.Column(c); column != nil {
+			e.Fields = append(e.Fields, column.GoName)
+		}
+	}
+	t.Edges = append(t.Edges, e)
+}
+
+// references returns the number of foreign keys of the table referencing the given table.
+func (t *TableInfo) references(table string) int {
+	n := 0
+	for _, fk := range t.ForeignKeys {
+		if fk.RefTable == table {
+			n++
+		}
+	}
+	return n
+}
+
+// isUnique reports if the columns hold the primary key or a unique index of the table.
+func (t *TableInfo) isUnique(columns []string) bool {
+	for _, idx := range t.Indexes {
+		if idx.Unique && len(idx.Columns) == len(columns) && subset(idx.Columns, columns) {
+			return true
+		}
+	}
+	return false
+}
+
+// subset reports if all strings of a are in b.
+func subset(a, b []string) bool {
+	for _, s := range a {
+		if !contains(b, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// edgeName returns the Go name of an edge from the columns of its foreign key
+// without their id suffix (user_id => User), or the singular of the given name
+// if the columns are composite or have no other name.
+func edgeName(columns []string, name string) string {
+	if len(columns) != 1 {
+		return singular(name)
+	}
+	column := strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(columns[0]), `id`), `_`)
+	if column == `` {
+		return singular(name)
+	}
+	return camel(&column)
+}
+
+// funcs are the helpers of the generator templates.
+var funcs = template.FuncMap{
+	`camel`:      func(s string) string { return camel(&s) },
+	`lowerCamel`: lowerCamel,
+	`snake`:      snakeCase,
+	`plural`:     plural,
+	`singular`:   singular,
+	`lower`:      strings.ToLower,
+	`upper`:      strings.ToUpper,
+	`join`:       func(sep string, s []string) string { return strings.Join(s, sep) },
+	`replace`:    strings.ReplaceAll,
+	`trimPrefix`: strings.TrimPrefix,
+	`hasPrefix`:  strings.HasPrefix,
+	`quote`:      strconv.Quote,
+	`comment`:    comment,
+	`protoType`:  protoType,
+	`add`:        func(a, b int) int { return a + b },
+	`quotes`:     quotes,
+	`deref`:      deref,
+	`receiver`:   receiver,
+	`param`:      param,
+}
+
+// param returns the name of a function parameter holding the values of a
+// column (user_id => userId). Names that are Go keywords or used by the
+// generated functions get an underscore suffix.
+func param(column string) string {
+	name := lowerCamel(column)
+	switch {
+	case token.IsKeyword(name), name == `ctx`, name == `v`, name == `r`, name == `db`:
+		return name + `_`
+	}
+	return name
+}
+
+// receiver returns the receiver name of the methods of a Go type (UserInfo => u).
+func receiver(typ string) string {
+	if typ == `` {
+		return `x`
+	}
+	return strings.ToLower(typ[:1])
+}
+
+// quotes returns the Go string literals of the strings, separated by commas.
+func quotes(s []string) string {
+	q := make([]string, len(s))
+	for i := range s {
+		q[i] = strconv.Quote(s[i])
+	}
+	return strings.Join(q, `, `)
+}
+
+// deref returns the string the pointer points to, or "".
+func deref(s *string) string {
+	if s == nil {
+		return ``
+	}
+	return *s
+}
+
+// lowerCamel returns the camel-cased name with a lower-cased first letter (user_info => userInfo).
+func lowerCamel(s string) string {
+	s = camel(&s)
+	if s == `` {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// snakeCase converts a Go name to its snake-cased form (UserInfo => user_info).
+func snakeCase(s string) string {
+	b := strings.Builder{}
+	rs := []rune(s)
+	for i, r := range rs {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && (rs[i-1] >= 'a' && rs[i-1] <= 'z' || i+1 < len(rs) && rs[i+1] >= 'a' && rs[i+1] <= 'z' && rs[i-1] != '_') {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// plural returns the plural form of an English noun (user => users, category => categories).
+// Plural nouns are kept (users => users), as the names of tables often are.
+func plural(s string) string {
+	s = singular(s)
+	switch {
+	case s == ``:
+		return s
+	case strings.HasSuffix(s, `y`) && len(s) > 1 && !strings.ContainsAny(s[len(s)-2:len(s)-1], `aeiou`):
+		return s[:len(s)-1] + `ies`
+	case strings.HasSuffix(s, `s`), strings.HasSuffix(s, `x`), strings.HasSuffix(s, `ch`), strings.HasSuffix(s, `sh`):
+		return s + `es`
+	default:
+		return s + `s`
+	}
+}
+
+// singular returns the singular form of an English noun (users => user, categories => category).
+// Nouns ending with ss, us or is are considered singular (address, status, analysis).
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, `ies`) && len(s) > 3:
+		return s[:len(s)-3] + `y`
+	case strings.HasSuffix(s, `sses`), strings.HasSuffix(s, `xes`), strings.HasSuffix(s, `ches`), strings.HasSuffix(s, `shes`):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, `ss`), strings.HasSuffix(s, `us`), strings.HasSuffix(s, `is`):
+		return s
+	case strings.HasSuffix(s, `s`):
+		return s[:len(s)-1]
+	default:
+		return s
+	}
+}
+
+// comment returns the text on a single line, for comments of the generated code.
+func comment(s string) string {
+	return strings.Join(strings.Fields(s), ` `)
+}
+
+// protoType returns the protobuf scalar type of a Go type.
+func protoType(goType string) string {
+	switch strings.TrimPrefix(goType, `*`) {
+	case `bool`, `sql.NullBool`:
+		return `bool`
+	case `int8`, `int16`, `int32`, `sql.NullInt16`, `sql.NullInt32`:
+		return `int32`
+	case `int`, `int64`, `sql.NullInt64`:
+		return `int64`
+	case `uint8`, `uint16`, `uint32`, `sql.NullByte`:
+		return `uint32`
+	case `uint`, `uint64`:
+		return `uint64`
+	case `float32`:
+		return `float`
+	case `float64`, `sql.NullFloat64`:
+		return `double`
+	case `[]byte`:
+		return `bytes`
+	case `time.Time`, `sql.NullTime`:
+		return `google.protobuf.Timestamp`
+	default:
+		return `string`
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateColumns(t *testing.T) {
+	golden(t, `users`, generateDDL(t, `mysql`, []string{`users.sql`}))
+}
+
+func TestGenerateKeys(t *testing.T) {
+	golden(t, `shop`, generateDDL(t, `mysql`, []string{`shop.sql`}))
+}
+
+func TestGenerateEdges(t *testing.T) {
+	src := string(generateDDL(t, `mysql`, []string{`shop.sql`}))
+	for _, method := range []string{
+		`func (u *Users) QueryOrders(`,
+		`func (u *Users) QueryProfile(`,
+		`func (o *Orders) QueryUser(`,
+		`func (o *Orders) QueryProducts(`,
+		`func (p *Products) QueryOrders(`,
+		`func (o *OrderProducts) QueryOrder(`,
+	} {
+		if !strings.Contains(src, method) {
+			t.Errorf("missing %s...)", method)
+		}
+	}
+}
+
+func TestPlural(t *testing.T) {
+	for s, want := range map[string]string{
+		`User`:     `Users`,
+		`Users`:    `Users`,
+		`Order`:    `Orders`,
+		`Orders`:   `Orders`,
+		`Category`: `Categories`,
+		`Key`:      `Keys`,
+		`Box`:      `Boxes`,
+		`Branches`: `Branches`,
+		`Status`:   `Statuses`,
+		`Address`:  `Addresses`,
+		``:         ``,
+	} {
+		if got := plural(s); got != want {
+			t.Errorf("plural(%q) = %q, want %q", s, got, want)
+		}
+	}
+	for s, want := range map[string]string{
+		`Users`:      `User`,
+		`User`:       `User`,
+		`Categories`: `Category`,
+		`Boxes`:      `Box`,
+		`Addresses`:  `Address`,
+		`Status`:     `Status`,
+		`Profiles`:   `Profile`,
+	} {
+		if got := singular(s); got != want {
+			t.Errorf("singular(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestGenerateRepo(t *testing.T) {
+	golden(t, `repo`, generateDDL(t, `mysql`, []string{`shop.sql`}, `--repo`))
+}
+
+func TestGenerateEnums(t *testing.T) {
+	golden(t, `enums`, generateDDL(t, `mysql`, []string{`enums.sql`}))
+	golden(t, `enums_pg`, generateDDL(t, `postgres`, []string{`enums_pg.sql`}))
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/liqiongfan/leopards"
+	_ "github.com/liqiongfan/leopards/sqlite"
+)
+
+// SqliteTable is a table of sqlite_master.
+type SqliteTable struct {
+	TableName   string `json:"name"`
+	SQL         string `json:"sql"`
+	Columns     []SqliteColumn
+	Indexes     []SqliteIndex
+	ForeignKeys []SqliteForeignKey
+}
+
+// SqliteColumn is a column of pragma_table_info.
+type SqliteColumn struct {
+	ColumnName string  `json:"name"`
+	Type       string  `json:"type"`
+	NotNull    bool    `json:"notnull"`
+	Default    *string `json:"dflt_value"`
+	PrimaryKey int     `json:"pk"` // position in the primary key, or 0.
+}
+
+// SqliteIndex is an indexed column of pragma_index_list and pragma_index_info.
+type SqliteIndex struct {
+	IndexName  string `json:"index_name"`
+	IsUnique   bool   `json:"is_unique"`
+	Origin     string `json:"origin"` // c: CREATE INDEX, u: UNIQUE, pk: PRIMARY KEY.
+	ColumnName string `json:"column_name"`
+}
+
+// SqliteForeignKey is a column of a foreign key of pragma_foreign_key_list.
+type SqliteForeignKey struct {
+	ID         int     `json:"id"`
+	ColumnName string  `json:"from"`
+	RefTable   string  `json:"table"`
+	RefColumn  *string `json:"to"` // nil for the primary key of the referenced table.
+	UpdateRule string  `json:"on_update"`
+	DeleteRule string  `json:"on_delete"`
+}
+
+// sqliteIndexes selects the indexed columns of a table in index order.
+const sqliteIndexes = `SELECT l.name AS index_name, l."unique" AS is_unique, l.origin AS origin, i.name AS column_name
+FROM pragma_index_list(?) AS l, pragma_index_info(l.name) AS i
+WHERE i.name IS NOT NULL
+ORDER BY l.name, i.seqno`
+
+// sqliteIntrospect reads the tables of the SQLite database file. The names
+// are separated by commas, or * for all the tables of the database.
+func sqliteIntrospect(ctx context.Context, path, names string) ([]SqliteTable, error) {
+	db, err := leopards.Open(leopards.SQLite, `file:`+path+`?mode=ro`)
+	if err != nil {
+		return nil, err
+	}
+	query := db.Query().
+		Select(`name`, `sql`).
+		From(`sqlite_master`).
+		Where(leopards.EQ(`type`, `table`)).
+		Where(leopards.Not(leopards.HasPrefix(`name`, `sqlite_`))).
+		OrderBy(leopards.Asc(`name`))
+	if names != `*` {
+		ins := make([]any, 0, 20)
+		for _, name := range strings.Split(names, `,`) {
+			ins = append(ins, strings.TrimSpace(name))
+		}
+		query.Where(leopards.In(`name`, ins...))
+	}
+	tables := make([]SqliteTable, 0, 20)
+	if err := query.Scan(ctx, &tables); err != nil {
+		return nil, err
+	}
+
+	for i, table := range tables {
+		err = db.Raw(ctx, `SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid`, table.TableName).
+			Scan(&tables[i].Columns)
+		if err != nil {
+			return nil, err
+		}
+		err = db.Raw(ctx, sqliteIndexes, table.TableName).Scan(&tables[i].Indexes)
+		if err != nil {
+			return nil, err
+		}
+		err = db.Raw(ctx, `SELECT id, "from", "table", "to", on_update, on_delete FROM pragma_foreign_key_list(?) ORDER BY id, seq`, table.TableName).
+			Scan(&tables[i].ForeignKeys)
+		if err != nil {
+			return nil, err
+		}
+		// Foreign keys referencing the primary key implicitly.
+		var primary []string
+		for j, fk := range tables[i].ForeignKeys {
+			if fk.RefColumn != nil {
+				continue
+			}
+			if j == 0 || fk.ID != tables[i].ForeignKeys[j-1].ID {
+				primary = nil
+				err = db.Raw(ctx, `SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk`, fk.RefTable).Scan(&primary)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if n := j - firstKeyColumn(tables[i].ForeignKeys, j); n < len(primary) {
+				tables[i].ForeignKeys[j].RefColumn = &primary[n]
+			}
+		}
+	}
+	return tables, nil
+}
+
+// firstKeyColumn returns the position of the first column of the foreign key
+// of the j-th column.
+func firstKeyColumn(fks []SqliteForeignKey, j int) int {
+	i := j
+	for i > 0 && fks[i-1].ID == fks[j].ID {
+		i--
+	}
+	return i
+}
+
+// sqliteSchema converts the SQLite tables to the data model of the templates.
+// The primary keys of SQLite have no index, unless they are WITHOUT ROWID
+// tables or not a single INTEGER column. The INTEGER PRIMARY KEY columns are
+// aliases of the rowid, filled by the database.
+func sqliteSchema(cfg *Config, database string, tables []SqliteTable) *Schema {
+	s := &Schema{Dialect: leopards.SQLite, Database: database}
+	for _, table := range tables {
+		t := &TableInfo{Name: table.TableName}
+		primary := make([]string, 0, len(table.Columns))
+		for _, column := range table.Columns {
+			c := &ColumnInfo{
+				Name:       column.ColumnName,
+				ColumnType: strings.ToLower(column.Type),
+				Nullable:   !column.NotNull && column.PrimaryKey == 0,
+				Default:    column.Default,
+			}
+			c.DataType, _ = typeArgs(c.ColumnType)
+			if _, args := typeArgs(c.ColumnType); len(args) > 0 && strings.Contains(c.DataType, `char`) {
+				c.Size = args[0]
+			}
+			cfg.columnTypes(t.Name, c, func(c *ColumnInfo) (string, bool) { return sqliteType(c.DataType), true })
+			t.Columns = append(t.Columns, c)
+		}
+		// The primary key columns in key order.
+		for i := 1; i <= len(table.Columns); i++ {
+			for _, column := range table.Columns {
+				if column.PrimaryKey == i {
+					primary = append(primary, column.ColumnName)
+				}
+			}
+		}
+		for _, name := range primary {
+			t.addIndex(`PRIMARY`, true, true, name)
+		}
+		if len(primary) == 1 {
+			c := t.Column(primary[0])
+			c.AutoIncrement = c.DataType == `integer` && !strings.Contains(strings.ToUpper(table.SQL), `WITHOUT ROWID`)
+		}
+		for _, idx := range table.Indexes {
+			if idx.Origin != `pk` {
+				t.addIndex(idx.IndexName, idx.IsUnique, false, idx.ColumnName)
+			}
+		}
+		for _, fk := range table.ForeignKeys {
+			if fk.RefColumn == nil {
+				continue
+			}
+			// SQLite foreign keys have no name.
+			name := table.TableName + `_fkey_` + strconv.Itoa(fk.ID)
+			t.addForeignKey(name, fk.ColumnName, fk.RefTable, *fk.RefColumn, fk.UpdateRule, fk.DeleteRule)
+		}
+		t.keys()
+		t.goNames()
+		cfg.tags(t)
+		s.Tables = append(s.Tables, t)
+	}
+	s.edges()
+	s.enums()
+	return s
+}
+
+// sqliteType returns the Go type of the values of a SQLite column by the
+// affinity of its declared type. Date and time types are scanned as time.Time
+// by the driver.
+func sqliteType(dataType string) string {
+	switch {
+	case dataType == `bool`, dataType == `boolean`:
+		return `bool`
+	case dataType == `date`, dataType == `datetime`, dataType == `timestamp`:
+		return `time.Time`
+	case strings.Contains(dataType, `int`):
+		return `int64`
+	case strings.Contains(dataType, `char`), strings.Contains(dataType, `clob`), strings.Contains(dataType, `text`):
+		return `string`
+	case dataType == ``, strings.Contains(dataType, `blob`):
+		return `[]byte`
+	case strings.Contains(dataType, `real`), strings.Contains(dataType, `floa`), strings.Contains(dataType, `doub`):
+		return `float64`
+	default:
+		return `string`
+	}
+}
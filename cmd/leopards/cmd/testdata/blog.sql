@@ -0,0 +1,31 @@
+-- A pg_dump of the blog database.
+SET statement_timeout = 0;
+
+CREATE TYPE public.post_status AS ENUM ('draft', 'published', 'archived');
+CREATE DOMAIN public.email AS character varying(128) CHECK (VALUE LIKE '%@%');
+
+CREATE TABLE public.authors (
+    id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    email public.email NOT NULL UNIQUE,
+    name text NOT NULL,
+    active boolean DEFAULT true NOT NULL
+);
+
+CREATE TABLE public.posts (
+    id serial NOT NULL,
+    author_id bigint NOT NULL REFERENCES public.authors (id) ON DELETE CASCADE,
+    title character varying(200) NOT NULL,
+    body text,
+    status public.post_status DEFAULT 'draft'::public.post_status NOT NULL,
+    tags text[] DEFAULT '{}'::text[] NOT NULL,
+    scores integer[],
+    price numeric(10,2),
+    meta jsonb,
+    published_at timestamp with time zone,
+    CONSTRAINT posts_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX idx_posts_author ON public.posts USING btree (author_id, published_at);
+
+COMMENT ON TABLE public.posts IS 'blog posts';
+COMMENT ON COLUMN public.posts.title IS 'title of the post';
@@ -0,0 +1,20 @@
+USE `shop`;
+
+CREATE TABLE `orders` (
+  `id` int NOT NULL,
+  `status` enum('on','off') NOT NULL,
+  PRIMARY KEY (`id`)
+);
+
+CREATE TABLE `payments` (
+  `id` int NOT NULL,
+  `status` enum('pending','paid','failed') NOT NULL,
+  PRIMARY KEY (`id`)
+);
+
+CREATE TABLE `shipments` (
+  `id` int NOT NULL,
+  `status` enum('on','off') DEFAULT NULL,
+  `state` enum('on','off') NOT NULL,
+  PRIMARY KEY (`id`)
+);
@@ -0,0 +1,18 @@
+CREATE SCHEMA billing;
+
+CREATE TYPE public.status AS ENUM ('active', 'blocked');
+CREATE TYPE billing.status AS ENUM ('open', 'paid');
+CREATE TYPE mood AS ENUM ('happy', 'sad');
+
+CREATE TABLE users (
+    id integer PRIMARY KEY,
+    status status NOT NULL,
+    mood mood
+);
+
+CREATE TABLE billing.invoices (
+    id integer PRIMARY KEY,
+    status billing.status NOT NULL,
+    previous billing.status,
+    moods mood[]
+);
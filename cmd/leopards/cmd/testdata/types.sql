@@ -0,0 +1,18 @@
+USE `shop`;
+
+CREATE TABLE `products` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `parent_id` bigint unsigned DEFAULT NULL,
+  `stock` int unsigned DEFAULT NULL,
+  `name` varchar(64) DEFAULT NULL,
+  `price` decimal(10,2) NOT NULL,
+  `discount` decimal(10,2) DEFAULT NULL,
+  `active` tinyint(1) NOT NULL DEFAULT 1,
+  `image` mediumblob,
+  `location` point DEFAULT NULL,
+  `extra` json DEFAULT NULL,
+  `sku` uuid NOT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_sku` (`sku`)
+);
@@ -0,0 +1,12 @@
+USE `shop`;
+
+CREATE TABLE `users` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'user id',
+  `name` varchar(64) NOT NULL DEFAULT '',
+  `age` int NOT NULL DEFAULT 0,
+  `email` varchar(128) DEFAULT NULL,
+  `status` enum('active','in-progress','banned') NOT NULL DEFAULT 'active',
+  `roles` set('admin','editor') DEFAULT NULL,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB COMMENT='users of the shop';
@@ -1,7 +1,13 @@
 package main
 
-import "github.com/liqiongfan/leopards/cmd/leopards/cmd"
+import (
+	"os"
+
+	"github.com/liqiongfan/leopards/cmd/leopards/cmd"
+)
 
 func main() {
-	_ = cmd.RootCMD.Execute()
+	if err := cmd.RootCMD.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
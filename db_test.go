@@ -0,0 +1,54 @@
+package leopards
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	_ "github.com/liqiongfan/leopards/sqlite"
+)
+
+// openSQLite opens an in-memory SQLite database holding the tables of the
+// given statements. The database has a single connection, as each
+// connection of :memory: is a database of its own.
+func openSQLite(t *testing.T, statements ...string) *DB {
+	t.Helper()
+	db, err := Open(SQLite, `file::memory:`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.driver.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.driver.Close() })
+	for _, statement := range statements {
+		if _, err := db.Exec(context.Background(), statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	return db
+}
+
+// assertSQL checks the statement and the arguments rendered by the querier.
+func assertSQL(t *testing.T, q interface {
+	ToSQL() (string, []any, error)
+}, statement string, args ...any) {
+	t.Helper()
+	query, qargs, err := q.ToSQL()
+	if err != nil {
+		t.Fatalf("ToSQL: %v", err)
+	}
+	if query != statement {
+		t.Errorf("statement:\n got: %s\nwant: %s", query, statement)
+	}
+	assertArgs(t, qargs, args)
+}
+
+// assertArgs checks the arguments of a statement.
+func assertArgs(t *testing.T, args, want []any) {
+	t.Helper()
+	if len(args) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("args:\n got: %#v\nwant: %#v", args, want)
+	}
+}
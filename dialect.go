@@ -0,0 +1,408 @@
+package leopards
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dialector describes a database dialect: how statements are rendered
+// for the database, and how a connection to it is opened. The built-in
+// MySQL, Postgres and SQLite dialects are implemented through it, and
+// other dialects can be added with RegisterDialect.
+type Dialector interface {
+	// Name returns the name of the dialect. For example, "postgres".
+	// It is the name passed to Dialect, Open and OpenOptions.Dialect.
+	Name() string
+	// DriverName returns the name of the database/sql driver.
+	DriverName() string
+	// DSN returns the data source name of the given options.
+	DSN(opt *OpenOptions) (string, error)
+	// IdentQuote returns the characters used to quote identifiers.
+	IdentQuote() (open, close byte)
+	// Placeholder returns the placeholder of the n-th (1-based) argument.
+	Placeholder(n int) string
+	// ColumnType returns the column type of the given Go type. The size
+	// is the size of the column (e.g. VARCHAR(size)), or zero if not set.
+	ColumnType(typ reflect.Type, size int) (string, error)
+	// Features returns the syntax supported by the dialect.
+	Features() Features
+}
+
+// UpsertStyle is the syntax of an upsert (INSERT ... ON CONFLICT) statement.
+type UpsertStyle uint8
+
+// Upsert styles.
+const (
+	UpsertNone           UpsertStyle = iota // not supported
+	UpsertOnConflict                        // ON CONFLICT (...) DO UPDATE SET ...
+	UpsertOnDuplicateKey                    // ON DUPLICATE KEY UPDATE ...
+	UpsertMerge                             // MERGE INTO ... USING (VALUES ...) ...
+)
+
+// ReturningStyle is the syntax for returning the rows affected by a statement.
+type ReturningStyle uint8
+
+// Returning styles.
+const (
+	ReturningNone   ReturningStyle = iota // not supported, the clause is omitted
+	ReturningClause                       // ... RETURNING ...
+	ReturningOutput                       // ... OUTPUT INSERTED.* ...
+)
+
+// LimitStyle is the syntax for limiting the rows of a statement.
+type LimitStyle uint8
+
+// Limit styles.
+const (
+	LimitNone   LimitStyle = iota // not supported
+	LimitClause                   // ... LIMIT n OFFSET m
+	LimitFetch                    // ... OFFSET m ROWS FETCH NEXT n ROWS ONLY
+	LimitTop                      // UPDATE/DELETE TOP (n) ...
+)
+
+// Features describes the syntax supported by a dialect.
+type Features struct {
+	Upsert          UpsertStyle    // INSERT ... ON CONFLICT syntax.
+	Returning       ReturningStyle // RETURNING syntax of INSERT and UPDATE.
+	Limit           LimitStyle     // LIMIT/OFFSET syntax of SELECT.
+	MutationLimit   LimitStyle     // ORDER BY/LIMIT syntax of UPDATE and DELETE.
+	DefaultValues   string         // INSERT clause of a row with default values only.
+	Schema          bool           // Table names can be qualified with a schema.
+	AlterColumnType bool           // ALTER COLUMN c TYPE t instead of MODIFY COLUMN c t.
+	Lock            bool           // SELECT ... FOR UPDATE/SHARE.
+	AutoIncrement   string         // Column attribute of autoIncrement columns.
+	AddColumn       string         // ALTER TABLE clause adding a column, ADD COLUMN if empty.
+	RowAlias        bool           // ON DUPLICATE KEY UPDATE refers to the inserted row by an alias (MySQL 8.0.19+) instead of VALUES().
+	TableColumns    string         // Query selecting the column names of a table of the current schema, in order. AutoMigrate is not supported if empty.
+	TableIndexes    string         // Query selecting the index names of a table of the current schema.
+}
+
+var dialects = struct {
+	sync.RWMutex
+	m map[string]Dialector
+}{m: make(map[string]Dialector)}
+
+// RegisterDialect makes a dialect available by its name. Registering
+// a dialect with the name of a built-in one replaces it.
+//
+//	leopards.RegisterDialect(clickhouse.Dialect{})
+//	db, err := leopards.OpenOptions{Dialect: `clickhouse`, ...}.Open()
+func RegisterDialect(d Dialector) {
+	if d == nil {
+		panic("leopards: RegisterDialect dialect is nil")
+	}
+	dialects.Lock()
+	defer dialects.Unlock()
+	dialects.m[d.Name()] = d
+}
+
+// GetDialect returns the dialect registered with the given name.
+func GetDialect(name string) (Dialector, bool) {
+	dialects.RLock()
+	defer dialects.RUnlock()
+	d, ok := dialects.m[name]
+	return d, ok
+}
+
+// Dialects returns the sorted names of the registered dialects.
+func Dialects() []string {
+	dialects.RLock()
+	defer dialects.RUnlock()
+	names := make([]string, 0, len(dialects.m))
+	for name := range dialects.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkDialect returns an error if a dialect name was given but not
+// registered, e.g. misspelled. Builders without a dialect use the MySQL
+// syntax, and are always valid.
+func checkDialect(name string) error {
+	if name == "" || name == Gremlin {
+		return nil
+	}
+	if _, ok := GetDialect(name); !ok {
+		return fmt.Errorf("leopards: unknown dialect %q, expect one of %v", name, Dialects())
+	}
+	return nil
+}
+
+// dialectOf returns the dialect registered with the given name,
+// or the MySQL dialect (the default syntax) if it was not found.
+// Unknown names are reported by checkDialect when rendering.
+func dialectOf(name string) Dialector {
+	if d, ok := GetDialect(name); ok {
+		return d
+	}
+	return mysqlDialect
+}
+
+// dialect implements the built-in dialects.
+type dialect struct {
+	name        string
+	driver      string // driver name, if it is not the dialect name.
+	quote       [2]byte
+	features    Features
+	types       map[string]string
+	placeholder func(int) string
+	dsn         func(*OpenOptions) (string, error)
+}
+
+var (
+	mysqlDialect = &dialect{
+		name:  MySQL,
+		quote: [2]byte{'`', '`'},
+		features: Features{
+			Upsert:        UpsertOnDuplicateKey,
+			Limit:         LimitClause,
+			MutationLimit: LimitClause,
+			DefaultValues: "VALUES ()",
+			Schema:        true,
+			Lock:          true,
+			AutoIncrement: "AUTO_INCREMENT",
+			TableColumns:  "SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position",
+			TableIndexes:  "SELECT DISTINCT index_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ?",
+		},
+		types: map[string]string{
+			"bool": "boolean", "int8": "tinyint", "int16": "smallint", "int32": "int", "int64": "bigint",
+			"uint8": "tinyint unsigned", "uint16": "smallint unsigned", "uint32": "int unsigned", "uint64": "bigint unsigned",
+			"float32": "float", "float64": "double", "string": "varchar(255)", "varchar": "varchar(%d)",
+			"bytes": "blob", "time": "timestamp", "json": "json",
+		},
+		placeholder: func(int) string { return "?" },
+		dsn: func(opt *OpenOptions) (string, error) {
+			if opt.Charset == `` {
+				opt.Charset = `utf8mb4,utf8`
+			}
+			interpolate := `true`
+			if opt.DisableInterpolateParams {
+				interpolate = `false`
+			}
+			var query strings.Builder
+			for i, p := range opt.params(`interpolateParams`, interpolate, `loc`, `Local`, `parseTime`, `True`, `timeTruncate`, `1s`, `charset`, opt.Charset) {
+				if i > 0 {
+					query.WriteByte('&')
+				}
+				query.WriteString(p[0] + `=` + p[1])
+			}
+			return opt.User + `:` + opt.Password + `@(` + opt.Host + `:` + opt.Port + `)/` + opt.Database + `?` + query.String(), nil
+		},
+	}
+	postgresDialect = &dialect{
+		name:  Postgres,
+		quote: [2]byte{'"', '"'},
+		features: Features{
+			Upsert:          UpsertOnConflict,
+			Returning:       ReturningClause,
+			Limit:           LimitClause,
+			DefaultValues:   "DEFAULT VALUES",
+			Schema:          true,
+			AlterColumnType: true,
+			Lock:            true,
+			AutoIncrement:   "GENERATED BY DEFAULT AS IDENTITY",
+			TableColumns:    "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position",
+			TableIndexes:    "SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = $1",
+		},
+		types: map[string]string{
+			"bool": "boolean", "int8": "smallint", "int16": "smallint", "int32": "integer", "int64": "bigint",
+			"uint8": "smallint", "uint16": "integer", "uint32": "bigint", "uint64": "numeric(20)",
+			"float32": "real", "float64": "double precision", "string": "text", "varchar": "varchar(%d)",
+			"bytes": "bytea", "time": "timestamp with time zone", "json": "jsonb",
+		},
+		// Postgres' arguments are referenced using the syntax $n.
+		// $1 refers to the 1st argument, $2 to the 2nd, and so on.
+		placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+		// host=<host> port=<port> user=<user> dbname=<database> password=<pass> sslmode=<mode>
+		dsn: func(opt *OpenOptions) (string, error) {
+			var b strings.Builder
+			for i, p := range opt.params(`host`, opt.Host, `port`, opt.Port, `user`, opt.User, `dbname`, opt.Database, `password`, opt.Password) {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString(p[0] + `=` + pgValue(p[1]))
+			}
+			return b.String(), nil
+		},
+	}
+	sqliteDialect = &dialect{
+		name:  SQLite,
+		quote: [2]byte{'`', '`'},
+		features: Features{
+			Upsert:        UpsertOnConflict,
+			Returning:     ReturningClause,
+			Limit:         LimitClause,
+			MutationLimit: LimitClause,
+			DefaultValues: "DEFAULT VALUES",
+			// AUTOINCREMENT is allowed only on an INTEGER PRIMARY KEY column.
+			AutoIncrement: "PRIMARY KEY AUTOINCREMENT",
+			TableColumns:  "SELECT name FROM pragma_table_info(?)",
+			TableIndexes:  "SELECT name FROM pragma_index_list(?)",
+		},
+		types: map[string]string{
+			"bool": "bool", "int8": "integer", "int16": "integer", "int32": "integer", "int64": "integer",
+			"uint8": "integer", "uint16": "integer", "uint32": "integer", "uint64": "integer",
+			"float32": "real", "float64": "real", "string": "text", "varchar": "varchar(%d)",
+			"bytes": "blob", "time": "datetime", "json": "json",
+		},
+		placeholder: func(int) string { return "?" },
+		// file:ent?mode=memory&cache=shared&_fk=1
+		dsn: func(opt *OpenOptions) (string, error) {
+			dsn := opt.FileForSQLite
+			for i, p := range opt.params(`mode`, `memory`, `cache`, `shared`) {
+				sep := `&`
+				if i == 0 {
+					sep = `?`
+				}
+				dsn += sep + p[0] + `=` + p[1]
+			}
+			return dsn, nil
+		},
+	}
+	sqlserverDialect = &dialect{
+		name:  SQLServer,
+		quote: [2]byte{'[', ']'},
+		features: Features{
+			Upsert:        UpsertMerge,
+			Returning:     ReturningOutput,
+			Limit:         LimitFetch,
+			MutationLimit: LimitTop,
+			DefaultValues: "DEFAULT VALUES",
+			Schema:        true,
+			AutoIncrement: "IDENTITY(1,1)",
+			AddColumn:     "ADD",
+			TableColumns:  "SELECT column_name FROM information_schema.columns WHERE table_schema = SCHEMA_NAME() AND table_name = @p1 ORDER BY ordinal_position",
+			TableIndexes:  "SELECT name FROM sys.indexes WHERE object_id = OBJECT_ID(QUOTENAME(SCHEMA_NAME()) + '.' + QUOTENAME(@p1)) AND name IS NOT NULL",
+		},
+		types: map[string]string{
+			"bool": "bit", "int8": "smallint", "int16": "smallint", "int32": "int", "int64": "bigint",
+			"uint8": "tinyint", "uint16": "int", "uint32": "bigint", "uint64": "decimal(20, 0)",
+			"float32": "real", "float64": "float", "string": "nvarchar(max)", "varchar": "nvarchar(%d)",
+			"bytes": "varbinary(max)", "time": "datetime2", "json": "nvarchar(max)",
+		},
+		placeholder: func(n int) string { return "@p" + strconv.Itoa(n) },
+		// sqlserver://<user>:<pass>@<host>:<port>?database=<database>
+		dsn: func(opt *OpenOptions) (string, error) {
+			query := url.Values{}
+			for _, p := range opt.params(`database`, opt.Database) {
+				query.Set(p[0], p[1])
+			}
+			u := &url.URL{
+				Scheme:   `sqlserver`,
+				User:     url.UserPassword(opt.User, opt.Password),
+				Host:     opt.Host + `:` + opt.Port,
+				RawQuery: query.Encode(),
+			}
+			return u.String(), nil
+		},
+	}
+)
+
+// pgValue quotes the values of the key=value connection
+// strings of Postgres holding spaces, quotes or backslashes.
+func pgValue(v string) string {
+	if !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	return `'` + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + `'`
+}
+
+// mysql8Dialect is the MySQL dialect of MySQL 8.0.19+, whose upserts
+// refer to the inserted row by an alias instead of the VALUES() function.
+var mysql8Dialect = func() *dialect {
+	d := *mysqlDialect
+	d.name, d.driver = MySQL8, MySQL
+	d.features.RowAlias = true
+	return &d
+}()
+
+func init() {
+	RegisterDialect(mysqlDialect)
+	RegisterDialect(mysql8Dialect)
+	RegisterDialect(postgresDialect)
+	RegisterDialect(sqliteDialect)
+	RegisterDialect(sqlserverDialect)
+}
+
+func (d *dialect) Name() string                         { return d.name }
+func (d *dialect) DSN(opt *OpenOptions) (string, error) { return d.dsn(opt) }
+func (d *dialect) IdentQuote() (open, close byte)       { return d.quote[0], d.quote[1] }
+func (d *dialect) Placeholder(n int) string             { return d.placeholder(n) }
+func (d *dialect) Features() Features                   { return d.features }
+func (d *dialect) DriverName() string {
+	if d.driver != "" {
+		return d.driver
+	}
+	return d.name
+}
+
+func (d *dialect) ColumnType(typ reflect.Type, size int) (string, error) {
+	class, ok := typeClass(typ)
+	if !ok {
+		return "", fmt.Errorf("%s: unsupported column type: %v", d.name, typ)
+	}
+	if class == "string" && size > 0 {
+		return fmt.Sprintf(d.types["varchar"], size), nil
+	}
+	return d.types[class], nil
+}
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	timeType       = reflect.TypeOf(time.Time{})
+	nullTypes      = map[reflect.Type]string{
+		reflect.TypeOf(sql.NullBool{}):    "bool",
+		reflect.TypeOf(sql.NullByte{}):    "uint8",
+		reflect.TypeOf(sql.NullInt16{}):   "int16",
+		reflect.TypeOf(sql.NullInt32{}):   "int32",
+		reflect.TypeOf(sql.NullInt64{}):   "int64",
+		reflect.TypeOf(sql.NullFloat64{}): "float64",
+		reflect.TypeOf(sql.NullString{}):  "string",
+		reflect.TypeOf(sql.NullTime{}):    "time",
+	}
+)
+
+// typeClass returns the class of the given Go type used for mapping it to a
+// column type. For example, "int64" for int, *int64 and sql.NullInt64.
+func typeClass(typ reflect.Type) (string, bool) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if class, ok := nullTypes[typ]; ok {
+		return class, true
+	}
+	switch {
+	case typ == timeType:
+		return "time", true
+	case typ == rawMessageType:
+		return "json", true
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "bool", true
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return typ.Kind().String(), true
+	case reflect.Int:
+		return "int64", true
+	case reflect.Uint:
+		return "uint64", true
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "bytes", true
+		}
+	case reflect.Map, reflect.Struct:
+		return "json", true
+	}
+	return "", false
+}
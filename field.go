@@ -0,0 +1,73 @@
+package leopards
+
+// Field is a column of a table whose values have the Go type T. Its methods
+// build the predicates of the column with typed arguments, and are used by the
+// code generated by the leopards command:
+//
+//	db.Query().From(models.UserTable).Where(leopards.And(
+//		models.UserWhere.Age.GT(18),
+//		models.UserWhere.Name.In(`a`, `b`),
+//	))
+type Field[T any] string
+
+// Name returns the column name of the field.
+func (f Field[T]) Name() string { return string(f) }
+
+// EQ returns a `column = value` predicate.
+func (f Field[T]) EQ(v T) *Predicate { return EQ(string(f), v) }
+
+// NEQ returns a `column <> value` predicate.
+func (f Field[T]) NEQ(v T) *Predicate { return NEQ(string(f), v) }
+
+// GT returns a `column > value` predicate.
+func (f Field[T]) GT(v T) *Predicate { return GT(string(f), v) }
+
+// GTE returns a `column >= value` predicate.
+func (f Field[T]) GTE(v T) *Predicate { return GTE(string(f), v) }
+
+// LT returns a `column < value` predicate.
+func (f Field[T]) LT(v T) *Predicate { return LT(string(f), v) }
+
+// LTE returns a `column <= value` predicate.
+func (f Field[T]) LTE(v T) *Predicate { return LTE(string(f), v) }
+
+// Between returns a `column BETWEEN v1 AND v2` predicate.
+func (f Field[T]) Between(v1, v2 T) *Predicate { return Between(string(f), v1, v2) }
+
+// In returns a `column IN (values)` predicate.
+func (f Field[T]) In(vs ...T) *Predicate { return In(string(f), anySlice(vs)...) }
+
+// NotIn returns a `column NOT IN (values)` predicate.
+func (f Field[T]) NotIn(vs ...T) *Predicate { return NotIn(string(f), anySlice(vs)...) }
+
+// IsNull returns a `column IS NULL` predicate.
+func (f Field[T]) IsNull() *Predicate { return IsNull(string(f)) }
+
+// NotNull returns a `column IS NOT NULL` predicate.
+func (f Field[T]) NotNull() *Predicate { return NotNull(string(f)) }
+
+// Like returns a `column LIKE pattern` predicate.
+func (f Field[T]) Like(pattern string) *Predicate { return Like(string(f), pattern) }
+
+// Contains returns a predicate checking if the column contains the substring.
+func (f Field[T]) Contains(sub string) *Predicate { return Contains(string(f), sub) }
+
+// HasPrefix returns a predicate checking if the column starts with the prefix.
+func (f Field[T]) HasPrefix(prefix string) *Predicate { return HasPrefix(string(f), prefix) }
+
+// HasSuffix returns a predicate checking if the column ends with the suffix.
+func (f Field[T]) HasSuffix(suffix string) *Predicate { return HasSuffix(string(f), suffix) }
+
+// Asc returns the column for ordering in ascending order.
+func (f Field[T]) Asc() string { return Asc(string(f)) }
+
+// Desc returns the column for ordering in descending order.
+func (f Field[T]) Desc() string { return Desc(string(f)) }
+
+func anySlice[T any](vs []T) []any {
+	args := make([]any, len(vs))
+	for i := range vs {
+		args[i] = vs[i]
+	}
+	return args
+}
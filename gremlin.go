@@ -0,0 +1,129 @@
+package leopards
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/liqiongfan/leopards/gremlin"
+)
+
+// GraphQuery executes a Gremlin traversal on the server of a Gremlin DB.
+type GraphQuery struct {
+	traversal *gremlin.Traversal
+	driver    *DB
+}
+
+// Traverse returns a query executing the traversal on the Gremlin server of the DB.
+//
+//	db, err := leopards.OpenOptions{Dialect: leopards.Gremlin, Host: `localhost`, Port: `8182`}.Open()
+//	err = db.Traverse(gremlin.G().V().HasLabel(`person`).Has(`name`, `marko`).Out(`knows`).ValueMap(true)).
+//		Scan(ctx, &people)
+func (b *DB) Traverse(t *gremlin.Traversal) *GraphQuery {
+	return &GraphQuery{traversal: t, driver: b}
+}
+
+// Exec executes the traversal and returns its results decoded from GraphSON.
+func (g *GraphQuery) Exec(ctx context.Context) ([]any, error) {
+	if g.driver.graph == nil {
+		return nil, fmt.Errorf("Traverse: dialect %q is not a Gremlin dialect", g.driver.dialect)
+	}
+	query, bindings := g.traversal.Query()
+	g.driver.logQuery(query, []any{bindings})
+	return g.driver.graph.Query(ctx, query, bindings)
+}
+
+// Scan executes the traversal and scans its results into dest, a pointer to
+// a struct, map, scalar or a slice of them. Element maps (e.g. the results of
+// valueMap, elementMap or vertices) are scanned into structs with the tag rules
+// used for SQL columns, and single-valued property lists are unwrapped.
+// If dest is not a slice and there are no results, Scan returns sql.ErrNoRows.
+func (g *GraphQuery) Scan(ctx context.Context, dest any) error {
+	values, err := g.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("Scan: dest must be a non-nil pointer, got %T", dest)
+	}
+	rv = rv.Elem()
+	if rv.Kind() == reflect.Slice && rv.Type() != reflect.TypeOf([]byte(nil)) {
+		for _, v := range values {
+			ev := reflect.New(rv.Type().Elem()).Elem()
+			if err := g.driver.setGraphValue(ev, v); err != nil {
+				return err
+			}
+			rv.Set(reflect.Append(rv, ev))
+		}
+		return nil
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("Scan: %w", sql.ErrNoRows)
+	}
+	return g.driver.setGraphValue(rv, values[0])
+}
+
+// setGraphValue sets a value decoded from GraphSON to dv.
+func (b *DB) setGraphValue(dv reflect.Value, v any) error {
+	if v == nil {
+		return nil
+	}
+	switch typ := dv.Type(); {
+	case typ.Kind() == reflect.Ptr:
+		nv := reflect.New(typ.Elem())
+		if err := b.setGraphValue(nv.Elem(), v); err != nil {
+			return err
+		}
+		dv.Set(nv)
+		return nil
+	case typ.Kind() == reflect.Struct && !isScalar(typ):
+		m, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("Scan: cannot scan %T into %v", v, typ)
+		}
+		names := b.parseEmbed(make(map[string][]int, typ.NumField()), typ, []int{}, 0)
+		for k, value := range m {
+			idx := names[k]
+			if idx == nil {
+				idx = names[strings.ToLower(k)]
+			}
+			if idx == nil {
+				continue
+			}
+			fv := dv.FieldByIndex(idx)
+			// valueMap returns the properties as lists.
+			if list, ok := value.([]any); ok && len(list) == 1 && fv.Kind() != reflect.Slice {
+				value = list[0]
+			}
+			if err := b.setGraphValue(fv, value); err != nil {
+				return fmt.Errorf("Scan: field %q: %w", k, err)
+			}
+		}
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	switch typ := dv.Type(); {
+	case rv.Type().AssignableTo(typ):
+		dv.Set(rv)
+	case isNumber(rv.Kind()) && isNumber(typ.Kind()), rv.Kind() == typ.Kind() && rv.Type().ConvertibleTo(typ):
+		dv.Set(rv.Convert(typ))
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, dv.Addr().Interface()); err != nil {
+			return errors.New("Scan: cannot scan " + rv.Type().String() + " into " + typ.String())
+		}
+	}
+	return nil
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
@@ -0,0 +1,186 @@
+package gremlin
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Status codes of the Gremlin server responses.
+const (
+	StatusSuccess        = 200
+	StatusNoContent      = 204
+	StatusPartialContent = 206
+)
+
+// Client executes traversals on a Gremlin server, over its HTTP endpoint
+// (http:// and https:// URLs) or its WebSocket endpoint (ws:// and wss://
+// URLs, e.g. ws://localhost:8182/gremlin). The WebSocket connection is
+// opened by the first request, and shared by the requests of the client,
+// which are sent one at a time.
+type Client struct {
+	url  string
+	http *http.Client
+
+	mu sync.Mutex
+	ws *wsConn // WebSocket connection, nil until the first request.
+}
+
+// NewClient returns a client sending requests to the given server URL
+// (e.g. http://localhost:8182 or ws://localhost:8182/gremlin). If hc is
+// nil, the http.DefaultClient is used. The TLS configuration of its
+// transport is used for wss:// URLs as well.
+func NewClient(url string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{url: url, http: hc}
+}
+
+// Close closes the WebSocket connection of the client, if any.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ws == nil {
+		return nil
+	}
+	err := c.ws.close()
+	c.ws = nil
+	return err
+}
+
+// Request is a Gremlin script request.
+type Request struct {
+	Gremlin  string         `json:"gremlin"`
+	Bindings map[string]any `json:"bindings,omitempty"`
+}
+
+// Response is the response of the Gremlin server.
+type Response struct {
+	RequestID string `json:"requestId"`
+	Status    struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+	Result struct {
+		Data json.RawMessage `json:"data"`
+	} `json:"result"`
+}
+
+// Error is returned for the responses with a non-success status code.
+type Error struct {
+	Code    int
+	Message string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("gremlin: status %d: %s", e.Code, e.Message)
+}
+
+// Do sends the request to the server and returns its response.
+func (c *Client) Do(ctx context.Context, r *Request) (*Response, error) {
+	if strings.HasPrefix(c.url, "ws://") || strings.HasPrefix(c.url, "wss://") {
+		return c.doWebSocket(ctx, r)
+	}
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("gremlin: encoding request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var rsp Response
+	if err := json.Unmarshal(data, &rsp); err != nil {
+		if res.StatusCode != http.StatusOK {
+			return nil, &Error{Code: res.StatusCode, Message: string(data)}
+		}
+		return nil, fmt.Errorf("gremlin: decoding response: %w", err)
+	}
+	// Servers report errors with the HTTP status and without the status object.
+	if rsp.Status.Code == 0 {
+		rsp.Status.Code = res.StatusCode
+	}
+	if code := rsp.Status.Code; code != StatusSuccess && code != StatusNoContent {
+		msg := rsp.Status.Message
+		if msg == "" {
+			msg = string(data)
+		}
+		return nil, &Error{Code: code, Message: msg}
+	}
+	return &rsp, nil
+}
+
+// doWebSocket sends the request over the WebSocket connection, opened if
+// needed. The connection is closed on failures other than error responses.
+func (c *Client) doWebSocket(ctx context.Context, r *Request) (*Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ws == nil {
+		var config *tls.Config
+		if t, ok := c.http.Transport.(*http.Transport); ok && t.TLSClientConfig != nil {
+			config = t.TLSClientConfig.Clone()
+		}
+		ws, err := dialWebSocket(ctx, c.url, config)
+		if err != nil {
+			return nil, err
+		}
+		c.ws = ws
+	}
+	rsp, err := c.ws.roundTrip(ctx, r)
+	var gerr *Error
+	if err != nil && !errors.As(err, &gerr) {
+		c.ws.conn.Close()
+		c.ws = nil
+	}
+	return rsp, err
+}
+
+// Query executes the Gremlin script and returns its results,
+// decoded from GraphSON to plain Go values (see Decode).
+func (c *Client) Query(ctx context.Context, script string, bindings map[string]any) ([]any, error) {
+	rsp, err := c.Do(ctx, &Request{Gremlin: script, Bindings: bindings})
+	if err != nil {
+		return nil, err
+	}
+	if rsp.Status.Code == StatusNoContent || len(rsp.Result.Data) == 0 {
+		return nil, nil
+	}
+	v, err := Decode(rsp.Result.Data)
+	if err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return v, nil
+	default:
+		return []any{v}, nil
+	}
+}
+
+// Execute executes the traversal and returns its results.
+func (c *Client) Execute(ctx context.Context, t *Traversal) ([]any, error) {
+	query, bindings := t.Query()
+	return c.Query(ctx, query, bindings)
+}
@@ -0,0 +1,234 @@
+package gremlin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		switch req.Gremlin {
+		case "g.V().has(p0, p1).values(p2)":
+			want := map[string]any{"p0": "name", "p1": "marko", "p2": "age"}
+			if !reflect.DeepEqual(req.Bindings, want) {
+				t.Errorf("bindings = %v, want %v", req.Bindings, want)
+			}
+			w.Write([]byte(`{"requestId":"1","status":{"code":200,"message":""},"result":{"data":{"@type":"g:List","@value":[{"@type":"g:Int32","@value":29}]},"meta":{}}}`))
+		case "g.V().count()":
+			w.Write([]byte(`{"requestId":"2","status":{"code":200},"result":{"data":{"@type":"g:Int64","@value":6}}}`))
+		case "g.V().drop()":
+			w.Write([]byte(`{"requestId":"3","status":{"code":204},"result":{"data":null}}`))
+		case "g.V(":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"message":"unexpected token","Exception-Class":"groovy.lang.MissingPropertyException"}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`Unauthorized`))
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(srv.URL, nil)
+	values, err := c.Execute(ctx, G().V().Has("name", "marko").Values("age"))
+	if err != nil || !reflect.DeepEqual(values, []any{int64(29)}) {
+		t.Errorf("Execute = %v, %v, want [29]", values, err)
+	}
+	values, err = c.Execute(ctx, G().V().Count())
+	if err != nil || !reflect.DeepEqual(values, []any{int64(6)}) {
+		t.Errorf("Execute = %v, %v, want [6]", values, err)
+	}
+	values, err = c.Execute(ctx, G().V().Drop())
+	if err != nil || values != nil {
+		t.Errorf("Execute = %v, %v, want no results", values, err)
+	}
+
+	var gerr *Error
+	if _, err = c.Query(ctx, "g.V(", nil); !errors.As(err, &gerr) || gerr.Code != http.StatusInternalServerError {
+		t.Errorf("Query error = %v, want status 500", err)
+	}
+	if _, err = c.Query(ctx, "g.E()", nil); !errors.As(err, &gerr) || gerr.Code != http.StatusUnauthorized || gerr.Message != "Unauthorized" {
+		t.Errorf("Query error = %v, want status 401", err)
+	}
+}
+
+// wsServer returns a handler serving the WebSocket endpoint of a Gremlin
+// server, answering the requests with the responses of the given function.
+func wsServer(t *testing.T, handshakes *int32, respond func(id string, req *Request) [][]byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gremlin" || r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "not a WebSocket request", http.StatusBadRequest)
+			return
+		}
+		atomic.AddInt32(handshakes, 1)
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijacking connection: %v", err)
+			return
+		}
+		defer conn.Close()
+		h := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + wsAcceptGUID))
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		brw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(h[:]) + "\r\n\r\n")
+		brw.Flush()
+		c := &wsConn{conn: conn, br: bufio.NewReader(brw)}
+		for {
+			msg, err := c.read()
+			if err != nil {
+				return
+			}
+			if n := int(msg[0]); len(msg) <= n || string(msg[1:n+1]) != wsMimeType {
+				t.Errorf("request without the %s mime type: %q", wsMimeType, msg)
+				return
+			}
+			var req wsRequest
+			if err := json.Unmarshal(msg[msg[0]+1:], &req); err != nil {
+				t.Errorf("decoding request: %v", err)
+				return
+			}
+			if req.Op != "eval" || req.Args.Language != "gremlin-groovy" {
+				t.Errorf("request = %+v, want an eval of gremlin-groovy", req)
+			}
+			for _, frame := range respond(req.RequestID, &Request{Gremlin: req.Args.Gremlin, Bindings: req.Args.Bindings}) {
+				if _, err := conn.Write(frame); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// serverFrame returns an unmasked frame of the server.
+func serverFrame(fin bool, opcode byte, payload string) []byte {
+	b := []byte{opcode, 0}
+	if fin {
+		b[0] |= 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		b[1] = byte(n)
+	default:
+		b[1] = 126
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	}
+	return append(b, payload...)
+}
+
+func TestClientWebSocket(t *testing.T) {
+	var handshakes int32
+	respond := func(id string, req *Request) [][]byte {
+		rsp := func(code int, data string) string {
+			return `{"requestId":"` + id + `","status":{"code":` + strconv.Itoa(code) + `,"message":"error"},"result":{"data":` + data + `,"meta":{}}}`
+		}
+		switch req.Gremlin {
+		case "g.V().has(p0, p1).values(p2)":
+			want := map[string]any{"p0": "name", "p1": "marko", "p2": "age"}
+			if !reflect.DeepEqual(req.Bindings, want) {
+				t.Errorf("bindings = %v, want %v", req.Bindings, want)
+			}
+			partial := rsp(StatusPartialContent, `{"@type":"g:List","@value":[{"@type":"g:Int32","@value":29}]}`)
+			last := rsp(StatusSuccess, `{"@type":"g:List","@value":[{"@type":"g:Int32","@value":27}]}`)
+			return [][]byte{
+				// The response of another request.
+				serverFrame(true, opText, `{"requestId":"other","status":{"code":200},"result":{"data":[1]}}`),
+				serverFrame(true, opPing, "ping"),
+				serverFrame(true, opBinary, partial),
+				// A fragmented message, with a long payload.
+				serverFrame(false, opText, last[:10]),
+				serverFrame(true, opContinuation, last[10:]+strings.Repeat(" ", 200)),
+			}
+		case "g.V().drop()":
+			return [][]byte{serverFrame(true, opText, rsp(StatusNoContent, `null`))}
+		case "g.V(":
+			return [][]byte{serverFrame(true, opText, rsp(597, `null`))}
+		default:
+			return nil // No response, until the request is canceled.
+		}
+	}
+
+	srv := httptest.NewServer(wsServer(t, &handshakes, respond))
+	defer srv.Close()
+	ctx := context.Background()
+	c := NewClient("ws"+strings.TrimPrefix(srv.URL, "http")+"/gremlin", nil)
+	defer c.Close()
+	values, err := c.Execute(ctx, G().V().Has("name", "marko").Values("age"))
+	if err != nil || !reflect.DeepEqual(values, []any{int64(29), int64(27)}) {
+		t.Errorf("Execute = %v, %v, want [29 27]", values, err)
+	}
+	values, err = c.Execute(ctx, G().V().Drop())
+	if err != nil || values != nil {
+		t.Errorf("Execute = %v, %v, want no results", values, err)
+	}
+	var gerr *Error
+	if _, err = c.Query(ctx, "g.V(", nil); !errors.As(err, &gerr) || gerr.Code != 597 || gerr.Message != "error" {
+		t.Errorf("Query error = %v, want status 597", err)
+	}
+	if n := atomic.LoadInt32(&handshakes); n != 1 {
+		t.Errorf("handshakes = %d, want the connection to be shared", n)
+	}
+
+	// The connection of a canceled request is dropped, and opened again.
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err = c.Query(tctx, "g.V().sleep()", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Query error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err = c.Execute(ctx, G().V().Drop()); err != nil {
+		t.Errorf("Execute error = %v", err)
+	}
+	if n := atomic.LoadInt32(&handshakes); n != 2 {
+		t.Errorf("handshakes = %d, want 2", n)
+	}
+
+	if _, err = NewClient("ws"+strings.TrimPrefix(srv.URL, "http")+"/other", nil).Query(ctx, "g.V()", nil); !errors.As(err, &gerr) || gerr.Code != http.StatusBadRequest {
+		t.Errorf("Query error = %v, want a handshake error", err)
+	}
+
+	tlsSrv := httptest.NewTLSServer(wsServer(t, &handshakes, respond))
+	defer tlsSrv.Close()
+	c = NewClient("wss"+strings.TrimPrefix(tlsSrv.URL, "https")+"/gremlin", tlsSrv.Client())
+	defer c.Close()
+	if values, err = c.Execute(ctx, G().V().Has("name", "marko").Values("age")); err != nil || len(values) != 2 {
+		t.Errorf("Execute = %v, %v, want [29 27]", values, err)
+	}
+}
+
+func TestMergeData(t *testing.T) {
+	tests := []struct {
+		chunks []string
+		want   string
+	}{
+		{[]string{`[1,2]`, `[3]`}, `[1,2,3]`},
+		{[]string{`{"@type":"g:List","@value":[1]}`, `{"@type":"g:List","@value":[2]}`}, `{"@type":"g:List","@value":[1,2]}`},
+		{[]string{`[1]`, `null`}, `[1]`},
+		{[]string{`null`, `null`}, `[]`},
+	}
+	for _, tt := range tests {
+		var chunks []json.RawMessage
+		for _, c := range tt.chunks {
+			chunks = append(chunks, json.RawMessage(c))
+		}
+		got, err := mergeData(chunks)
+		if err != nil || !bytes.Equal(got, []byte(tt.want)) {
+			t.Errorf("mergeData(%v) = %s, %v, want %s", tt.chunks, got, err, tt.want)
+		}
+	}
+}
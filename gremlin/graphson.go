@@ -0,0 +1,145 @@
+package gremlin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Decode decodes GraphSON (v1, v2 and v3) data to plain Go values:
+//
+//   - Lists and sets are decoded to []any, and maps to map[string]any.
+//   - Integers are decoded to int64, and other numbers to float64.
+//   - Dates are decoded to time.Time, and UUIDs and tokens to strings.
+//   - Vertices and edges are decoded to maps holding their id, label
+//     and properties. Edges also hold their "inV" and "outV" ids.
+func Decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("gremlin: decoding graphson: %w", err)
+	}
+	return unwrap(v), nil
+}
+
+func unwrap(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i := range v {
+			v[i] = unwrap(v[i])
+		}
+		return v
+	case map[string]any:
+		if typ, ok := v["@type"].(string); ok {
+			if value, ok := v["@value"]; ok && len(v) == 2 {
+				return unwrapTyped(typ, value)
+			}
+		}
+		// GraphSON v1 elements.
+		if _, ok := v["properties"]; ok && (v["type"] == "vertex" || v["type"] == "edge") {
+			return element(v)
+		}
+		for k := range v {
+			v[k] = unwrap(v[k])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func unwrapTyped(typ string, v any) any {
+	switch typ {
+	case "g:Map":
+		list, _ := v.([]any)
+		m := make(map[string]any, len(list)/2)
+		for i := 0; i+1 < len(list); i += 2 {
+			m[fmt.Sprint(unwrap(list[i]))] = unwrap(list[i+1])
+		}
+		return m
+	case "g:BulkSet":
+		list, _ := v.([]any)
+		var values []any
+		for i := 0; i+1 < len(list); i += 2 {
+			value := unwrap(list[i])
+			bulk, _ := unwrap(list[i+1]).(int64)
+			for j := int64(0); j < bulk; j++ {
+				values = append(values, value)
+			}
+		}
+		return values
+	case "g:Date", "g:Timestamp":
+		ms, _ := unwrap(v).(int64)
+		return time.UnixMilli(ms)
+	case "g:Float", "g:Double":
+		switch f := unwrap(v).(type) {
+		case int64:
+			return float64(f)
+		default:
+			return f
+		}
+	case "g:Vertex", "g:Edge":
+		m, _ := v.(map[string]any)
+		return element(m)
+	case "g:VertexProperty", "g:Property":
+		if m, ok := v.(map[string]any); ok {
+			return unwrap(m["value"])
+		}
+		return unwrap(v)
+	case "g:Path":
+		if m, ok := v.(map[string]any); ok {
+			return unwrap(m["objects"])
+		}
+		return unwrap(v)
+	default: // g:List, g:Set, g:Int32, g:Int64, g:UUID, g:T, g:Direction, ...
+		return unwrap(v)
+	}
+}
+
+// element flattens a vertex or an edge to a map of its id, label and properties.
+func element(v map[string]any) map[string]any {
+	m := make(map[string]any, len(v))
+	for k, value := range v {
+		switch k {
+		case "properties", "type":
+		default:
+			m[k] = unwrap(value)
+		}
+	}
+	props, _ := unwrap(v["properties"]).(map[string]any)
+	for k, value := range props {
+		// Vertex properties are lists of properties (multi-properties).
+		if list, ok := value.([]any); ok {
+			if len(list) == 1 {
+				value = list[0]
+			} else {
+				values := make([]any, len(list))
+				for i := range list {
+					values[i] = property(list[i])
+				}
+				value = values
+			}
+		}
+		m[k] = property(value)
+	}
+	return m
+}
+
+// property returns the value of a property object.
+func property(v any) any {
+	if m, ok := v.(map[string]any); ok {
+		if value, ok := m["value"]; ok {
+			return value
+		}
+	}
+	return v
+}
@@ -0,0 +1,68 @@
+package gremlin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want any
+	}{
+		{
+			name: "v1 vertex",
+			data: `[{"id":1,"label":"person","type":"vertex","properties":{"name":[{"id":0,"value":"marko"}],"age":[{"id":2,"value":29}]}}]`,
+			want: []any{map[string]any{"id": int64(1), "label": "person", "name": "marko", "age": int64(29)}},
+		},
+		{
+			name: "v3 vertex",
+			data: `{"@type":"g:List","@value":[{"@type":"g:Vertex","@value":{"id":{"@type":"g:Int64","@value":1},"label":"person",` +
+				`"properties":{"name":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"g:Int64","@value":0},"value":"marko","label":"name"}}],` +
+				`"nick":[{"@type":"g:VertexProperty","@value":{"value":"a"}},{"@type":"g:VertexProperty","@value":{"value":"b"}}]}}}]}`,
+			want: []any{map[string]any{"id": int64(1), "label": "person", "name": "marko", "nick": []any{"a", "b"}}},
+		},
+		{
+			name: "v3 edge",
+			data: `{"@type":"g:Edge","@value":{"id":{"@type":"g:Int32","@value":7},"label":"knows","inV":2,"outV":1,` +
+				`"properties":{"weight":{"@type":"g:Property","@value":{"key":"weight","value":{"@type":"g:Double","@value":0.5}}}}}}`,
+			want: map[string]any{"id": int64(7), "label": "knows", "inV": int64(2), "outV": int64(1), "weight": 0.5},
+		},
+		{
+			name: "valueMap",
+			data: `{"@type":"g:List","@value":[{"@type":"g:Map","@value":[{"@type":"g:T","@value":"id"},{"@type":"g:Int64","@value":1},"name",{"@type":"g:List","@value":["marko"]}]}]}`,
+			want: []any{map[string]any{"id": int64(1), "name": []any{"marko"}}},
+		},
+		{
+			name: "bulk set",
+			data: `{"@type":"g:BulkSet","@value":["a",{"@type":"g:Int64","@value":2},"b",{"@type":"g:Int64","@value":1}]}`,
+			want: []any{"a", "a", "b"},
+		},
+		{
+			name: "scalars",
+			data: `[{"@type":"g:Date","@value":1500000000000},{"@type":"g:Float","@value":2},{"@type":"g:UUID","@value":"41d2e28a-20a4-4ab0-b379-d810dede3786"},3.5]`,
+			want: []any{time.UnixMilli(1500000000000), 2.0, "41d2e28a-20a4-4ab0-b379-d810dede3786", 3.5},
+		},
+		{
+			name: "path",
+			data: `{"@type":"g:Path","@value":{"labels":{"@type":"g:List","@value":[]},"objects":{"@type":"g:List","@value":["marko","josh"]}}}`,
+			want: []any{"marko", "josh"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode = %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+	if _, err := Decode([]byte(`{`)); err == nil {
+		t.Error("Decode: expect an error for invalid JSON")
+	}
+}
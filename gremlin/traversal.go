@@ -0,0 +1,319 @@
+// Package gremlin provides a Gremlin traversal builder and a client
+// for executing traversals on a Gremlin server over HTTP.
+//
+//	t := gremlin.G().V().HasLabel("person").Has("name", "marko").Out("knows").ValueMap(true)
+//	query, bindings := t.Query()
+//	// g.V().hasLabel(p0).has(p1, p2).out(p3).valueMap().with(WithOptions.tokens)
+package gremlin
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Token is a Gremlin token written to the traversal as is (e.g. T.id).
+type Token string
+
+// Gremlin tokens.
+const (
+	ID    Token = "T.id"
+	Label Token = "T.label"
+	Key   Token = "T.key"
+	Value Token = "T.value"
+
+	Asc     Token = "Order.asc"
+	Desc    Token = "Order.desc"
+	Shuffle Token = "Order.shuffle"
+
+	Single Token = "single"
+	List   Token = "list"
+	Set    Token = "set"
+)
+
+// Predicate is a Gremlin predicate (e.g. P.gt(30)).
+type Predicate struct {
+	name string
+	args []any
+}
+
+// Eq is the `P.eq` predicate.
+func Eq(v any) *Predicate { return &Predicate{"P.eq", []any{v}} }
+
+// Neq is the `P.neq` predicate.
+func Neq(v any) *Predicate { return &Predicate{"P.neq", []any{v}} }
+
+// Gt is the `P.gt` predicate.
+func Gt(v any) *Predicate { return &Predicate{"P.gt", []any{v}} }
+
+// Gte is the `P.gte` predicate.
+func Gte(v any) *Predicate { return &Predicate{"P.gte", []any{v}} }
+
+// Lt is the `P.lt` predicate.
+func Lt(v any) *Predicate { return &Predicate{"P.lt", []any{v}} }
+
+// Lte is the `P.lte` predicate.
+func Lte(v any) *Predicate { return &Predicate{"P.lte", []any{v}} }
+
+// Between is the `P.between` predicate, with an inclusive start and an exclusive end.
+func Between(start, end any) *Predicate { return &Predicate{"P.between", []any{start, end}} }
+
+// Inside is the `P.inside` predicate, with exclusive bounds.
+func Inside(start, end any) *Predicate { return &Predicate{"P.inside", []any{start, end}} }
+
+// Outside is the `P.outside` predicate.
+func Outside(start, end any) *Predicate { return &Predicate{"P.outside", []any{start, end}} }
+
+// Within is the `P.within` predicate.
+func Within(vs ...any) *Predicate { return &Predicate{"P.within", vs} }
+
+// Without is the `P.without` predicate.
+func Without(vs ...any) *Predicate { return &Predicate{"P.without", vs} }
+
+// StartingWith is the `TextP.startingWith` predicate.
+func StartingWith(prefix string) *Predicate {
+	return &Predicate{"TextP.startingWith", []any{prefix}}
+}
+
+// Containing is the `TextP.containing` predicate.
+func Containing(substr string) *Predicate { return &Predicate{"TextP.containing", []any{substr}} }
+
+type step struct {
+	name string
+	args []any
+}
+
+// Traversal is a builder for Gremlin traversals.
+type Traversal struct {
+	source string
+	steps  []step
+}
+
+// G starts a traversal from the graph traversal source `g`.
+func G() *Traversal { return &Traversal{source: "g"} }
+
+// Anon starts an anonymous traversal (`__`), used as an argument of
+// other steps. For example:
+//
+//	G().V().Where(Anon().Out("knows").Has("name", "josh"))
+func Anon() *Traversal { return &Traversal{source: "__"} }
+
+// Step appends a step with the given name and arguments to the traversal.
+// It is used for steps without a dedicated method.
+func (t *Traversal) Step(name string, args ...any) *Traversal {
+	t.steps = append(t.steps, step{name: name, args: args})
+	return t
+}
+
+// Clone returns a duplicate of the traversal.
+func (t *Traversal) Clone() *Traversal {
+	return &Traversal{source: t.source, steps: append([]step(nil), t.steps...)}
+}
+
+// V appends the `V` step.
+func (t *Traversal) V(ids ...any) *Traversal { return t.Step("V", ids...) }
+
+// E appends the `E` step.
+func (t *Traversal) E(ids ...any) *Traversal { return t.Step("E", ids...) }
+
+// AddV appends the `addV` step.
+func (t *Traversal) AddV(label string) *Traversal { return t.Step("addV", label) }
+
+// AddE appends the `addE` step.
+func (t *Traversal) AddE(label string) *Traversal { return t.Step("addE", label) }
+
+// From appends the `from` step of `addE`, with a vertex traversal or step label.
+func (t *Traversal) From(v any) *Traversal { return t.Step("from", v) }
+
+// To appends the `to` step of `addE`, with a vertex traversal or step label.
+func (t *Traversal) To(v any) *Traversal { return t.Step("to", v) }
+
+// Property appends the `property` step. For example:
+//
+//	G().AddV("person").Property("name", "marko")
+//	G().V(1).Property(gremlin.Single, "age", 30)
+func (t *Traversal) Property(args ...any) *Traversal { return t.Step("property", args...) }
+
+// HasLabel appends the `hasLabel` step.
+func (t *Traversal) HasLabel(labels ...string) *Traversal { return t.Step("hasLabel", strs(labels)...) }
+
+// Has appends the `has` step. For example:
+//
+//	Has("name")
+//	Has("name", "marko")
+//	Has("age", gremlin.Gt(30))
+//	Has("person", "name", "marko")
+func (t *Traversal) Has(key string, values ...any) *Traversal {
+	return t.Step("has", append([]any{key}, values...)...)
+}
+
+// HasID appends the `hasId` step.
+func (t *Traversal) HasID(ids ...any) *Traversal { return t.Step("hasId", ids...) }
+
+// HasNot appends the `hasNot` step.
+func (t *Traversal) HasNot(key string) *Traversal { return t.Step("hasNot", key) }
+
+// Is appends the `is` step.
+func (t *Traversal) Is(v any) *Traversal { return t.Step("is", v) }
+
+// Out appends the `out` step.
+func (t *Traversal) Out(labels ...string) *Traversal { return t.Step("out", strs(labels)...) }
+
+// In appends the `in` step.
+func (t *Traversal) In(labels ...string) *Traversal { return t.Step("in", strs(labels)...) }
+
+// Both appends the `both` step.
+func (t *Traversal) Both(labels ...string) *Traversal { return t.Step("both", strs(labels)...) }
+
+// OutE appends the `outE` step.
+func (t *Traversal) OutE(labels ...string) *Traversal { return t.Step("outE", strs(labels)...) }
+
+// InE appends the `inE` step.
+func (t *Traversal) InE(labels ...string) *Traversal { return t.Step("inE", strs(labels)...) }
+
+// BothE appends the `bothE` step.
+func (t *Traversal) BothE(labels ...string) *Traversal { return t.Step("bothE", strs(labels)...) }
+
+// OutV appends the `outV` step.
+func (t *Traversal) OutV() *Traversal { return t.Step("outV") }
+
+// InV appends the `inV` step.
+func (t *Traversal) InV() *Traversal { return t.Step("inV") }
+
+// OtherV appends the `otherV` step.
+func (t *Traversal) OtherV() *Traversal { return t.Step("otherV") }
+
+// Values appends the `values` step.
+func (t *Traversal) Values(keys ...string) *Traversal { return t.Step("values", strs(keys)...) }
+
+// ValueMap appends the `valueMap` step. If withTokens is true, the id and label
+// of the elements are included in the maps.
+func (t *Traversal) ValueMap(withTokens bool, keys ...string) *Traversal {
+	t.Step("valueMap", strs(keys)...)
+	if withTokens {
+		t.Step("with", Token("WithOptions.tokens"))
+	}
+	return t
+}
+
+// ElementMap appends the `elementMap` step.
+func (t *Traversal) ElementMap(keys ...string) *Traversal { return t.Step("elementMap", strs(keys)...) }
+
+// Properties appends the `properties` step.
+func (t *Traversal) Properties(keys ...string) *Traversal { return t.Step("properties", strs(keys)...) }
+
+// ID appends the `id` step.
+func (t *Traversal) ID() *Traversal { return t.Step("id") }
+
+// Label appends the `label` step.
+func (t *Traversal) Label() *Traversal { return t.Step("label") }
+
+// Where appends the `where` step with a traversal or predicate.
+func (t *Traversal) Where(v any) *Traversal { return t.Step("where", v) }
+
+// Not appends the `not` step.
+func (t *Traversal) Not(v *Traversal) *Traversal { return t.Step("not", v) }
+
+// As labels the current step.
+func (t *Traversal) As(labels ...string) *Traversal { return t.Step("as", strs(labels)...) }
+
+// Select appends the `select` step.
+func (t *Traversal) Select(labels ...string) *Traversal { return t.Step("select", strs(labels)...) }
+
+// Order appends the `order` step.
+func (t *Traversal) Order() *Traversal { return t.Step("order") }
+
+// By appends the `by` modulator. For example:
+//
+//	Order().By("age", gremlin.Desc)
+func (t *Traversal) By(args ...any) *Traversal { return t.Step("by", args...) }
+
+// Limit appends the `limit` step.
+func (t *Traversal) Limit(n int) *Traversal { return t.Step("limit", Token(strconv.Itoa(n))) }
+
+// Range appends the `range` step.
+func (t *Traversal) Range(low, high int) *Traversal {
+	return t.Step("range", Token(strconv.Itoa(low)), Token(strconv.Itoa(high)))
+}
+
+// Dedup appends the `dedup` step.
+func (t *Traversal) Dedup() *Traversal { return t.Step("dedup") }
+
+// Count appends the `count` step.
+func (t *Traversal) Count() *Traversal { return t.Step("count") }
+
+// Fold appends the `fold` step.
+func (t *Traversal) Fold() *Traversal { return t.Step("fold") }
+
+// Unfold appends the `unfold` step.
+func (t *Traversal) Unfold() *Traversal { return t.Step("unfold") }
+
+// Path appends the `path` step.
+func (t *Traversal) Path() *Traversal { return t.Step("path") }
+
+// Drop appends the `drop` step.
+func (t *Traversal) Drop() *Traversal { return t.Step("drop") }
+
+// Query returns the Gremlin script of the traversal and its bindings.
+// Values are passed as bindings, and tokens, predicates and anonymous
+// traversals are written to the script.
+func (t *Traversal) Query() (string, map[string]any) {
+	b := &builder{bindings: make(map[string]any)}
+	b.traversal(t)
+	return b.String(), b.bindings
+}
+
+// String returns the Gremlin script of the traversal.
+func (t *Traversal) String() string {
+	query, _ := t.Query()
+	return query
+}
+
+// builder writes traversals and collects their bindings.
+type builder struct {
+	strings.Builder
+	bindings map[string]any
+}
+
+func (b *builder) traversal(t *Traversal) {
+	b.WriteString(t.source)
+	for _, s := range t.steps {
+		b.WriteByte('.')
+		b.WriteString(s.name)
+		b.args(s.args)
+	}
+}
+
+func (b *builder) args(args []any) {
+	b.WriteByte('(')
+	for i, arg := range args {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.arg(arg)
+	}
+	b.WriteByte(')')
+}
+
+func (b *builder) arg(arg any) {
+	switch v := arg.(type) {
+	case *Traversal:
+		b.traversal(v)
+	case Token:
+		b.WriteString(string(v))
+	case *Predicate:
+		b.WriteString(v.name)
+		b.args(v.args)
+	default:
+		name := "p" + strconv.Itoa(len(b.bindings))
+		b.bindings[name] = v
+		b.WriteString(name)
+	}
+}
+
+func strs(s []string) []any {
+	args := make([]any, len(s))
+	for i := range s {
+		args[i] = s[i]
+	}
+	return args
+}
@@ -0,0 +1,64 @@
+package gremlin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTraversalQuery(t *testing.T) {
+	tests := []struct {
+		t        *Traversal
+		script   string
+		bindings map[string]any
+	}{
+		{
+			t:        G().V().HasLabel("person").Has("age", Gt(30)).Out("knows").Values("name"),
+			script:   "g.V().hasLabel(p0).has(p1, P.gt(p2)).out(p3).values(p4)",
+			bindings: map[string]any{"p0": "person", "p1": "age", "p2": 30, "p3": "knows", "p4": "name"},
+		},
+		{
+			t:        G().V(1).Where(Anon().Out("created").Has("lang", Within("java", "go"))).ValueMap(true, "name"),
+			script:   "g.V(p0).where(__.out(p1).has(p2, P.within(p3, p4))).valueMap(p5).with(WithOptions.tokens)",
+			bindings: map[string]any{"p0": 1, "p1": "created", "p2": "lang", "p3": "java", "p4": "go", "p5": "name"},
+		},
+		{
+			t:        G().AddV("person").Property(Single, "name", "marko").Property("age", 29),
+			script:   "g.addV(p0).property(single, p1, p2).property(p3, p4)",
+			bindings: map[string]any{"p0": "person", "p1": "name", "p2": "marko", "p3": "age", "p4": 29},
+		},
+		{
+			t:        G().V().Order().By("age", Desc).Range(0, 10).Limit(5).Count(),
+			script:   "g.V().order().by(p0, Order.desc).range(0, 10).limit(5).count()",
+			bindings: map[string]any{"p0": "age"},
+		},
+		{
+			t:        G().V().Has("name", StartingWith("ma")).Step("simplePath"),
+			script:   "g.V().has(p0, TextP.startingWith(p1)).simplePath()",
+			bindings: map[string]any{"p0": "name", "p1": "ma"},
+		},
+	}
+	for _, tt := range tests {
+		script, bindings := tt.t.Query()
+		if script != tt.script {
+			t.Errorf("script:\n got: %s\nwant: %s", script, tt.script)
+		}
+		if !reflect.DeepEqual(bindings, tt.bindings) {
+			t.Errorf("%s: bindings = %v, want %v", tt.script, bindings, tt.bindings)
+		}
+		// Rendering is idempotent.
+		if again, _ := tt.t.Query(); again != script {
+			t.Errorf("second render = %s, want %s", again, script)
+		}
+	}
+}
+
+func TestTraversalClone(t *testing.T) {
+	base := G().V().HasLabel("person")
+	c := base.Clone().Count()
+	if got := base.String(); got != "g.V().hasLabel(p0)" {
+		t.Errorf("base = %s", got)
+	}
+	if got := c.String(); got != "g.V().hasLabel(p0).count()" {
+		t.Errorf("clone = %s", got)
+	}
+}
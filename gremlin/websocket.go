@@ -0,0 +1,356 @@
+package gremlin
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// WebSocket opcodes (RFC 6455).
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// wsAcceptGUID is appended to the key of the opening handshake.
+const wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsMaxMessage limits the size of the messages read from the server.
+const wsMaxMessage = 64 << 20
+
+// wsMimeType is the serializer of the requests, prefixed to their messages.
+const wsMimeType = "application/json"
+
+// wsConn is a client connection to the WebSocket endpoint of a Gremlin server.
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// wsRequest is the message of a script request.
+type wsRequest struct {
+	RequestID string `json:"requestId"`
+	Op        string `json:"op"`
+	Processor string `json:"processor"`
+	Args      struct {
+		Gremlin  string         `json:"gremlin"`
+		Bindings map[string]any `json:"bindings,omitempty"`
+		Language string         `json:"language"`
+	} `json:"args"`
+}
+
+// dialWebSocket opens a WebSocket connection to the given ws:// or wss:// URL.
+func dialWebSocket(ctx context.Context, rawURL string, config *tls.Config) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("gremlin: %w", err)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "wss" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "wss" {
+		if config == nil {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config.ServerName = u.Hostname()
+		}
+		tc := tls.Client(conn, config)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tc
+	}
+	c := &wsConn{conn: conn, br: bufio.NewReader(conn)}
+	if err := c.handshake(ctx, u); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// handshake upgrades the connection to the WebSocket protocol.
+func (c *wsConn) handshake(ctx context.Context, u *url.URL) error {
+	defer c.watch(ctx)()
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	nonce := base64.StdEncoding.EncodeToString(key)
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: u.Path, RawQuery: u.RawQuery},
+		Host:       u.Host,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Upgrade":               {"websocket"},
+			"Connection":            {"Upgrade"},
+			"Sec-WebSocket-Key":     {nonce},
+			"Sec-WebSocket-Version": {"13"},
+		},
+	}
+	if req.URL.Path == "" {
+		req.URL.Path = "/"
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		req.SetBasicAuth(u.User.Username(), password)
+	}
+	if err := req.Write(c.conn); err != nil {
+		return err
+	}
+	res, err := http.ReadResponse(c.br, req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		return &Error{Code: res.StatusCode, Message: "WebSocket handshake: " + res.Status}
+	}
+	h := sha1.Sum([]byte(nonce + wsAcceptGUID))
+	if res.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(h[:]) {
+		return errors.New("gremlin: WebSocket handshake: invalid Sec-WebSocket-Accept header")
+	}
+	return nil
+}
+
+// watch applies the deadline and the cancellation of the context to the
+// connection, until the returned function is called.
+func (c *wsConn) watch(ctx context.Context) func() {
+	deadline, _ := ctx.Deadline()
+	c.conn.SetDeadline(deadline)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.conn.SetDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// roundTrip sends the script request, and reads its responses until the last
+// one. The data of the partial (206) responses are merged into its result.
+func (c *wsConn) roundTrip(ctx context.Context, r *Request) (*Response, error) {
+	defer c.watch(ctx)()
+	req := wsRequest{RequestID: requestID(), Op: "eval"}
+	req.Args.Gremlin, req.Args.Bindings, req.Args.Language = r.Gremlin, r.Bindings, "gremlin-groovy"
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("gremlin: encoding request: %w", err)
+	}
+	msg := append([]byte{byte(len(wsMimeType))}, wsMimeType...)
+	if err := c.write(opBinary, append(msg, body...)); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	var chunks []json.RawMessage
+	for {
+		data, err := c.read()
+		if err != nil {
+			return nil, contextErr(ctx, err)
+		}
+		var rsp Response
+		if err := json.Unmarshal(data, &rsp); err != nil {
+			return nil, fmt.Errorf("gremlin: decoding response: %w", err)
+		}
+		// Responses of previous (e.g. canceled) requests.
+		if rsp.RequestID != req.RequestID {
+			continue
+		}
+		switch code := rsp.Status.Code; code {
+		case StatusPartialContent:
+			chunks = append(chunks, rsp.Result.Data)
+		case StatusSuccess, StatusNoContent:
+			if len(chunks) > 0 {
+				rsp.Result.Data, err = mergeData(append(chunks, rsp.Result.Data))
+			}
+			return &rsp, err
+		default:
+			return nil, &Error{Code: code, Message: rsp.Status.Message}
+		}
+	}
+}
+
+// write writes a masked frame holding the whole payload.
+func (c *wsConn) write(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode, 0x80}
+	switch n := len(payload); {
+	case n < 126:
+		frame[1] |= byte(n)
+	case n <= 0xFFFF:
+		frame[1] |= 126
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame[1] |= 127
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// read reads the next data message, answering the pings of the server.
+func (c *wsConn) read() ([]byte, error) {
+	var msg []byte
+	for {
+		var h [2]byte
+		if _, err := io.ReadFull(c.br, h[:]); err != nil {
+			return nil, err
+		}
+		fin, opcode, masked := h[0]&0x80 != 0, h[0]&0x0F, h[1]&0x80 != 0
+		n := uint64(h[1] & 0x7F)
+		switch n {
+		case 126:
+			var b [2]byte
+			if _, err := io.ReadFull(c.br, b[:]); err != nil {
+				return nil, err
+			}
+			n = uint64(binary.BigEndian.Uint16(b[:]))
+		case 127:
+			var b [8]byte
+			if _, err := io.ReadFull(c.br, b[:]); err != nil {
+				return nil, err
+			}
+			n = binary.BigEndian.Uint64(b[:])
+		}
+		var mask [4]byte
+		if masked {
+			if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+				return nil, err
+			}
+		}
+		if n > wsMaxMessage-uint64(len(msg)) {
+			return nil, fmt.Errorf("gremlin: WebSocket message larger than %d bytes", wsMaxMessage)
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(c.br, payload); err != nil {
+			return nil, err
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+		switch opcode {
+		case opPing:
+			if err := c.write(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			return nil, errors.New("gremlin: WebSocket connection closed by the server")
+		case opText, opBinary, opContinuation:
+		default:
+			return nil, fmt.Errorf("gremlin: unexpected WebSocket opcode %#x", opcode)
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// close sends a close frame and closes the connection.
+func (c *wsConn) close() error {
+	_ = c.write(opClose, []byte{0x03, 0xE8}) // 1000: normal closure.
+	return c.conn.Close()
+}
+
+// mergeData merges the result data of the partial responses of a request.
+// GraphSON v2 and v3 lists are merged into a list of the same type.
+func mergeData(chunks []json.RawMessage) (json.RawMessage, error) {
+	var (
+		typ    string
+		values []json.RawMessage
+	)
+	for _, chunk := range chunks {
+		var typed struct {
+			Type  string            `json:"@type"`
+			Value []json.RawMessage `json:"@value"`
+		}
+		var list []json.RawMessage
+		switch {
+		case len(chunk) == 0 || string(chunk) == "null":
+		case json.Unmarshal(chunk, &list) == nil:
+			values = append(values, list...)
+		case json.Unmarshal(chunk, &typed) == nil && typed.Type != "":
+			typ = typed.Type
+			values = append(values, typed.Value...)
+		default:
+			values = append(values, chunk)
+		}
+	}
+	if values == nil {
+		values = []json.RawMessage{}
+	}
+	if typ == "" {
+		return json.Marshal(values)
+	}
+	return json.Marshal(struct {
+		Type  string            `json:"@type"`
+		Value []json.RawMessage `json:"@value"`
+	}{typ, values})
+}
+
+// requestID returns a random (version 4) UUID.
+func requestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0F | 0x40
+	b[8] = b[8]&0x3F | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// contextErr returns the error of the context, if the
+// connection failed because the context is done.
+func contextErr(ctx context.Context, err error) error {
+	// The deadlines of the connection are set by the context only,
+	// and may expire slightly before the timer of the context.
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		<-ctx.Done()
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,344 @@
+package leopards
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// modelSchema is the table definition derived from a struct type.
+type modelSchema struct {
+	name    string
+	columns []modelColumn
+	primary []string
+	indexes []modelIndex
+}
+
+// modelColumn is a column definition derived from a struct field.
+type modelColumn struct {
+	name   string
+	typ    string
+	def    string // default value expression.
+	null   bool   // pointer and sql.Null* fields.
+	unique bool
+	auto   bool // autoIncrement.
+}
+
+// modelIndex is an index definition derived from the `index` and
+// `uniqueIndex` options of the fields sharing the same index name.
+type modelIndex struct {
+	name    string
+	unique  bool
+	columns []string
+}
+
+// schemaOf derives the table definition of the struct type from the `leopard` tag
+// options of its fields:
+//
+//	type User struct {
+//		ID      int64      `leopard:"column:id;primaryKey;autoIncrement"`
+//		Email   string     `leopard:"column:email;size:128;uniqueIndex"`
+//		Name    string     `leopard:"column:name;index:idx_name_age"`
+//		Age     int        `leopard:"column:age;index:idx_name_age;default:0"`
+//		Deleted *time.Time `leopard:"column:deleted_at"`
+//	}
+//
+// Column types are mapped by the dialect (or set with the `type` option), and
+// nullable columns are derived from pointer and sql.Null* fields.
+func schemaOf(dialect string, typ reflect.Type, table string) (*modelSchema, error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf(`CreateTableFrom: invalid type: %s. expect struct as argument`, typ)
+	}
+	if err := checkDialect(dialect); err != nil {
+		return nil, err
+	}
+	d := dialectOf(dialect)
+	s := &modelSchema{name: table}
+	for _, f := range modelFields(typ) {
+		ft := typ.FieldByIndex(f.index).Type
+		c := modelColumn{
+			name:   f.column,
+			typ:    f.opts[`type`],
+			def:    f.opts[`default`],
+			null:   isNullable(ft),
+			unique: f.has(`unique`),
+			auto:   f.has(`autoIncrement`),
+		}
+		if c.typ == `` {
+			size, _ := strconv.Atoi(f.opts[`size`])
+			t, err := d.ColumnType(ft, size)
+			if err != nil {
+				return nil, fmt.Errorf("CreateTableFrom: field %s: %w", f.name, err)
+			}
+			c.typ = t
+		}
+		if f.has(`primaryKey`) {
+			c.null = false
+			// The column holds the primary key constraint (e.g. SQLite).
+			if !(c.auto && strings.Contains(d.Features().AutoIncrement, `PRIMARY KEY`)) {
+				s.primary = append(s.primary, c.name)
+			}
+		}
+		for _, opt := range []string{`index`, `uniqueIndex`} {
+			if !f.has(opt) {
+				continue
+			}
+			name := f.opts[strings.ToLower(opt)]
+			if name == `` {
+				name = `idx_` + table + `_` + c.name
+			}
+			s.addIndex(name, opt == `uniqueIndex`, c.name)
+		}
+		s.columns = append(s.columns, c)
+	}
+	return s, nil
+}
+
+// addIndex adds the column to the index with the given name.
+func (s *modelSchema) addIndex(name string, unique bool, column string) {
+	for i := range s.indexes {
+		if s.indexes[i].name == name {
+			s.indexes[i].unique = s.indexes[i].unique || unique
+			s.indexes[i].columns = append(s.indexes[i].columns, column)
+			return
+		}
+	}
+	s.indexes = append(s.indexes, modelIndex{name: name, unique: unique, columns: []string{column}})
+}
+
+// column returns the definition of the column. Columns added to existing tables
+// are nullable unless they have a default value, and their unique constraints
+// are created as indexes.
+func (c modelColumn) column(dialect string, add bool) *ColumnBuilder {
+	b := Dialect(dialect).Column(c.name).Type(c.typ)
+	if !c.null && (!add || c.def != ``) {
+		b.Attr(`NOT NULL`)
+	}
+	if c.def != `` {
+		b.Attr(`DEFAULT ` + c.def)
+	}
+	if c.unique && !add {
+		b.Attr(`UNIQUE`)
+	}
+	if c.auto {
+		b.Attr(dialectOf(dialect).Features().AutoIncrement)
+	}
+	return b
+}
+
+// index returns the `CREATE INDEX` statement of the index.
+func (i modelIndex) index(dialect, table string) *IndexBuilder {
+	b := Dialect(dialect).CreateIndex(i.name).Table(table).Columns(i.columns...)
+	if i.unique {
+		b.Unique()
+	}
+	return b
+}
+
+// isNullable reports if the field type maps to a nullable column.
+func isNullable(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Pointer {
+		return true
+	}
+	_, ok := nullTypes[typ]
+	return ok
+}
+
+// CreateTableFrom returns a query builder for the `CREATE TABLE` statement of
+// the struct type T. The columns, their types (by the dialect of the builder),
+// nullability and primary key are derived from the fields and their `leopard`
+// tag options (see DB.AutoMigrate). The table name is the result of the
+// `TableName() string` method of T, or its snake-cased name.
+//
+//	sql, _, err := leopards.CreateTableFrom[User]().ToSQL()
+//
+// The indexes of the table are created with the statements returned by Indexes.
+func CreateTableFrom[T any]() *TableBuilder {
+	return createTableFrom(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// CreateTableFrom creates a TableBuilder for the struct type
+// of the given model for the configured dialect.
+//
+//	Dialect(leopards.Postgres).CreateTableFrom(&User{})
+func (d *DialectBuilder) CreateTableFrom(v any) *TableBuilder {
+	b := createTableFrom(reflect.TypeOf(v))
+	b.SetDialect(d.dialect)
+	return b
+}
+
+func createTableFrom(typ reflect.Type) *TableBuilder {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	t := CreateTable(``)
+	if typ == nil || typ.Kind() != reflect.Struct {
+		t.AddError(fmt.Errorf(`CreateTableFrom: invalid type: %v. expect struct as argument`, typ))
+		return t
+	}
+	t.name = modelTable(reflect.New(typ).Elem())
+	t.model = typ
+	return t
+}
+
+// modelColumns returns the columns and the primary key derived from the model.
+func (t *TableBuilder) modelColumns() ([]Querier, []string) {
+	s, err := schemaOf(t.dialect, t.model, t.name)
+	if err != nil {
+		t.AddError(err)
+		return nil, nil
+	}
+	columns := append([]Querier{}, t.columns...)
+	for _, c := range s.columns {
+		columns = append(columns, c.column(t.dialect, false))
+	}
+	return columns, append(s.primary, t.primary...)
+}
+
+// Indexes returns the `CREATE INDEX` statements of the indexes of the table
+// created with CreateTableFrom, to be executed after the table is created.
+func (t *TableBuilder) Indexes() []*IndexBuilder {
+	if t.model == nil {
+		return nil
+	}
+	s, err := schemaOf(t.dialect, t.model, t.name)
+	if err != nil {
+		t.AddError(err)
+		return nil
+	}
+	indexes := make([]*IndexBuilder, 0, len(s.indexes))
+	for _, idx := range s.indexes {
+		indexes = append(indexes, idx.index(t.dialect, t.name))
+	}
+	return indexes
+}
+
+// AutoMigrate creates the tables of the given models, and adds their missing
+// columns and indexes to existing tables. It never drops or modifies existing
+// tables, columns or indexes. The definitions of the tables are derived from
+// the fields of the models and these `leopard` tag options:
+//
+//   - primaryKey, autoIncrement: primary key and auto-increment columns.
+//   - type:varchar(64): the column type, instead of the type mapped by the dialect.
+//   - size:64: the size of string columns, e.g. varchar(64).
+//   - default:0: the default value expression.
+//   - unique: a unique column.
+//   - index, index:name: an index, shared by the fields with the same name.
+//   - uniqueIndex, uniqueIndex:name: a unique index.
+//
+// Pointer and sql.Null* fields are nullable, and the other fields are NOT NULL.
+// Columns added to existing tables are nullable unless they have a default value.
+// Indexes are compared by name: the declared indexes missing from an existing
+// table are created, whether their columns are new or not. The existing columns
+// and indexes are read with the TableColumns and TableIndexes queries of the
+// dialect features.
+//
+//	err := db.AutoMigrate(ctx, &User{}, &Order{})
+func (b *DB) AutoMigrate(ctx context.Context, models ...any) error {
+	f := dialectOf(b.dialect).Features()
+	if f.TableColumns == `` || f.TableIndexes == `` {
+		return fmt.Errorf("AutoMigrate: not supported by dialect %q", b.dialect)
+	}
+	for _, m := range models {
+		rv, err := modelValue(m)
+		if err != nil {
+			return err
+		}
+		s, err := schemaOf(b.dialect, rv.Type(), modelTable(rv))
+		if err != nil {
+			return err
+		}
+		columns, err := b.tableNames(ctx, f.TableColumns, s.name)
+		if err != nil {
+			return err
+		}
+		var indexes []string
+		if len(columns) > 0 {
+			if indexes, err = b.tableNames(ctx, f.TableIndexes, s.name); err != nil {
+				return err
+			}
+		}
+		if err := b.migrate(ctx, s, columns, indexes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrate creates the table, or adds its missing columns and indexes.
+func (b *DB) migrate(ctx context.Context, s *modelSchema, columns, indexes []string) error {
+	var statements []Querier
+	switch {
+	case len(columns) == 0:
+		t := Dialect(b.dialect).CreateTable(s.name).PrimaryKey(s.primary...)
+		for _, c := range s.columns {
+			t.Column(c.column(b.dialect, false))
+		}
+		statements = append(statements, t)
+		for _, idx := range s.indexes {
+			statements = append(statements, idx.index(b.dialect, s.name))
+		}
+	default:
+		for _, c := range s.columns {
+			if containsFold(columns, c.name) {
+				continue
+			}
+			// SQLite adds a single column per statement.
+			statements = append(statements, Dialect(b.dialect).AlterTable(s.name).AddColumn(c.column(b.dialect, true)))
+			if c.unique {
+				s.addIndex(`idx_`+s.name+`_`+c.name, true, c.name)
+			}
+		}
+		for _, idx := range s.indexes {
+			if !containsFold(indexes, idx.name) {
+				statements = append(statements, idx.index(b.dialect, s.name))
+			}
+		}
+	}
+	for _, q := range statements {
+		statement, args, err := toSQL(q)
+		if err != nil {
+			return err
+		}
+		if _, err := b.execContext(ctx, statement, args); err != nil {
+			return fmt.Errorf("AutoMigrate: %s: %w", s.name, err)
+		}
+	}
+	return nil
+}
+
+// tableNames returns the names (of columns or indexes) selected by the given
+// introspection query of the table, or none if the table does not exist.
+func (b *DB) tableNames(ctx context.Context, query, table string) ([]string, error) {
+	rows, err := b.queryContext(ctx, query, []any{table})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return names, rows.Close()
+}
+
+func containsFold(s []string, v string) bool {
+	for i := range s {
+		if strings.EqualFold(s[i], v) {
+			return true
+		}
+	}
+	return false
+}
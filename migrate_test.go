@@ -0,0 +1,150 @@
+package leopards
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type migrateUser struct {
+	ID        int64      `leopard:"column:id;primaryKey;autoIncrement"`
+	Email     string     `leopard:"column:email;size:128;uniqueIndex"`
+	Name      string     `leopard:"column:name;index:idx_name_age"`
+	Age       int        `leopard:"column:age;index:idx_name_age;default:0"`
+	Kind      string     `leopard:"type"` // a column named after an option.
+	DeletedAt *time.Time `leopard:"column:deleted_at"`
+}
+
+func TestCreateTableFrom(t *testing.T) {
+	tests := []struct {
+		dialect string
+		table   string
+		indexes []string
+	}{
+		{
+			dialect: MySQL,
+			table:   "CREATE TABLE `migrate_user`(`id` bigint NOT NULL AUTO_INCREMENT, `email` varchar(128) NOT NULL, `name` varchar(255) NOT NULL, `age` bigint NOT NULL DEFAULT 0, `type` varchar(255) NOT NULL, `deleted_at` timestamp, PRIMARY KEY(`id`))",
+			indexes: []string{
+				"CREATE UNIQUE INDEX `idx_migrate_user_email` ON `migrate_user`(`email`)",
+				"CREATE INDEX `idx_name_age` ON `migrate_user`(`name`, `age`)",
+			},
+		},
+		{
+			dialect: Postgres,
+			table:   `CREATE TABLE "migrate_user"("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, "email" varchar(128) NOT NULL, "name" text NOT NULL, "age" bigint NOT NULL DEFAULT 0, "type" text NOT NULL, "deleted_at" timestamp with time zone, PRIMARY KEY("id"))`,
+			indexes: []string{
+				`CREATE UNIQUE INDEX "idx_migrate_user_email" ON "migrate_user"("email")`,
+				`CREATE INDEX "idx_name_age" ON "migrate_user"("name", "age")`,
+			},
+		},
+		{
+			dialect: SQLite,
+			table:   "CREATE TABLE `migrate_user`(`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `email` varchar(128) NOT NULL, `name` text NOT NULL, `age` integer NOT NULL DEFAULT 0, `type` text NOT NULL, `deleted_at` datetime)",
+			indexes: []string{
+				"CREATE UNIQUE INDEX `idx_migrate_user_email` ON `migrate_user`(`email`)",
+				"CREATE INDEX `idx_name_age` ON `migrate_user`(`name`, `age`)",
+			},
+		},
+		{
+			dialect: SQLServer,
+			table:   "CREATE TABLE [migrate_user]([id] bigint NOT NULL IDENTITY(1,1), [email] nvarchar(128) NOT NULL, [name] nvarchar(max) NOT NULL, [age] bigint NOT NULL DEFAULT 0, [type] nvarchar(max) NOT NULL, [deleted_at] datetime2, PRIMARY KEY([id]))",
+			indexes: []string{
+				"CREATE UNIQUE INDEX [idx_migrate_user_email] ON [migrate_user]([email])",
+				"CREATE INDEX [idx_name_age] ON [migrate_user]([name], [age])",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			tb := Dialect(tt.dialect).CreateTableFrom(&migrateUser{})
+			assertSQL(t, tb, tt.table)
+			indexes := tb.Indexes()
+			if len(indexes) != len(tt.indexes) {
+				t.Fatalf("Indexes = %d, want %d", len(indexes), len(tt.indexes))
+			}
+			for i, idx := range indexes {
+				assertSQL(t, idx, tt.indexes[i])
+			}
+		})
+	}
+}
+
+func TestCreateTableFromGeneric(t *testing.T) {
+	query, _, err := CreateTableFrom[migrateUser]().ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _, _ := Dialect(MySQL).CreateTableFrom(&migrateUser{}).ToSQL()
+	if query != want {
+		t.Errorf("CreateTableFrom[T] = %s, want %s", query, want)
+	}
+}
+
+func TestAutoMigrate(t *testing.T) {
+	db := openSQLite(t, "CREATE TABLE `migrate_user` (`id` integer PRIMARY KEY, `name` text)")
+	ctx := context.Background()
+	if err := db.AutoMigrate(ctx, &migrateUser{}); err != nil {
+		t.Fatal(err)
+	}
+	columns, err := db.tableNames(ctx, sqliteDialect.features.TableColumns, `migrate_user`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`id`, `name`, `email`, `age`, `type`, `deleted_at`}
+	if !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+	var indexes []string
+	if err := db.Raw(ctx, "SELECT name FROM pragma_index_list('migrate_user') ORDER BY name").Scan(&indexes); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`idx_migrate_user_email`, `idx_name_age`}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("indexes = %v, want %v", indexes, want)
+	}
+	// Migrating again is a no-op.
+	if err := db.AutoMigrate(ctx, &migrateUser{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The declared indexes missing from an existing table are
+// created, even if all their columns exist.
+func TestAutoMigrateIndexes(t *testing.T) {
+	db := openSQLite(t,
+		"CREATE TABLE `migrate_user` (`id` integer PRIMARY KEY, `email` text, `name` text, `age` integer, `type` text, `deleted_at` datetime)",
+		"CREATE UNIQUE INDEX `idx_migrate_user_email` ON `migrate_user` (`email`)",
+	)
+	ctx := context.Background()
+	if err := db.AutoMigrate(ctx, &migrateUser{}); err != nil {
+		t.Fatal(err)
+	}
+	var indexes []string
+	if err := db.Raw(ctx, "SELECT name FROM pragma_index_list('migrate_user') ORDER BY name").Scan(&indexes); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`idx_migrate_user_email`, `idx_name_age`}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("indexes = %v, want %v", indexes, want)
+	}
+}
+
+// noIntrospection is a dialect without the introspection queries of AutoMigrate.
+type noIntrospection struct{ Dialector }
+
+func (noIntrospection) Name() string { return `nointrospection` }
+
+func (d noIntrospection) Features() Features {
+	f := d.Dialector.Features()
+	f.TableColumns, f.TableIndexes = ``, ``
+	return f
+}
+
+func TestAutoMigrateDialect(t *testing.T) {
+	RegisterDialect(noIntrospection{sqliteDialect})
+	db := openSQLite(t)
+	db.dialect = `nointrospection`
+	err := db.AutoMigrate(context.Background(), &migrateUser{})
+	if err == nil || err.Error() != `AutoMigrate: not supported by dialect "nointrospection"` {
+		t.Errorf("AutoMigrate = %v, want an unsupported dialect error", err)
+	}
+}
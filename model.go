@@ -0,0 +1,172 @@
+package leopards
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrStaleObject is returned (wrapped in a *StaleObjectError) when a
+// versioned update did not match any row, because the row was changed
+// or deleted since it was read.
+var ErrStaleObject = errors.New(`leopards: stale object`)
+
+// StaleObjectError describes a failed optimistic-locking update.
+type StaleObjectError struct {
+	Table   string // table name
+	Column  string // version column
+	Version any    // version the update expected
+}
+
+// Error implements the error interface.
+func (e *StaleObjectError) Error() string {
+	return fmt.Sprintf("%s: %s.%s = %v", ErrStaleObject, e.Table, e.Column, e.Version)
+}
+
+// Is reports whether target is ErrStaleObject.
+func (e *StaleObjectError) Is(target error) bool {
+	return target == ErrStaleObject
+}
+
+// tagOptions parses the options of the `leopard` tag, for example:
+//
+//	`leopard:"column:id;primaryKey;autoIncrement"`
+//
+// Option names are lower-cased, and options without value are mapped to "".
+// A tag holding a single word is a column name, even if it is the name of an
+// option: options are written with the column, as in `leopard:"column:ver;version"`.
+func tagOptions(f reflect.StructField) map[string]string {
+	tag, ok := f.Tag.Lookup(`leopard`)
+	if !ok || !isTagOptions(tag) {
+		return nil
+	}
+	opts := make(map[string]string)
+	for _, piece := range strings.Split(tag, `;`) {
+		piece = strings.TrimSpace(piece)
+		if piece == `` {
+			continue
+		}
+		k, v := piece, ``
+		if i := strings.Index(piece, `:`); i >= 0 {
+			k, v = piece[:i], piece[i+1:]
+		}
+		opts[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	return opts
+}
+
+// isTagOptions reports if the `leopard` tag value is a list of options
+// instead of a bare column name.
+func isTagOptions(tag string) bool {
+	return strings.ContainsAny(tag, `;:`)
+}
+
+// modelField is a struct field mapped to a table column.
+type modelField struct {
+	name   string            // struct field name.
+	column string            // column name.
+	index  []int             // field index sequence.
+	opts   map[string]string // `leopard` tag options.
+}
+
+// has reports if the field has the given `leopard` tag option.
+func (f modelField) has(opt string) bool {
+	_, ok := f.opts[strings.ToLower(opt)]
+	return ok
+}
+
+// modelFields returns the column fields of the given struct type, including
+// the fields of embedded structs. Fields tagged with "-" are skipped.
+func modelFields(typ reflect.Type) []modelField {
+	return appendModelFields(nil, typ, nil)
+}
+
+func appendModelFields(fields []modelField, typ reflect.Type, index []int) []modelField {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != `` {
+			continue
+		}
+		idx := append(append([]int{}, index...), i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			fields = appendModelFields(fields, f.Type, idx)
+			continue
+		}
+		column := columnName(f)
+		if column == `-` {
+			continue
+		}
+		fields = append(fields, modelField{name: f.Name, column: column, index: idx, opts: tagOptions(f)})
+	}
+	return fields
+}
+
+// modelValue returns the struct value the given model points to.
+func modelValue(v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return reflect.Value{}, errors.New(`Model: nil pointer of model`)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf(`Model: invalid type: %s. expect struct as argument`, rv.Type())
+	}
+	return rv, nil
+}
+
+// modelTable returns the table name of the model. Models may
+// define it with a `TableName() string` method, or a generated
+// Schema method, otherwise the snake-cased name of the struct
+// type is used.
+func modelTable(rv reflect.Value) string {
+	if t, ok := rv.Interface().(interface{ TableName() string }); ok {
+		return t.TableName()
+	}
+	if rv.CanAddr() {
+		if t, ok := rv.Addr().Interface().(interface{ TableName() string }); ok {
+			return t.TableName()
+		}
+	}
+	if s := modelTableSchema(rv.Interface()); s != nil && s.Name != `` {
+		return s.Name
+	}
+	return snakeCase(rv.Type().Name())
+}
+
+// snakeCase converts a Go name to its snake-cased form (UserID => user_id).
+func snakeCase(s string) string {
+	b := strings.Builder{}
+	rs := []rune(s)
+	for i, r := range rs {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && (rs[i-1] >= 'a' && rs[i-1] <= 'z' || i+1 < len(rs) && rs[i+1] >= 'a' && rs[i+1] <= 'z' && rs[i-1] != '_') {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// versionLock holds the version field of a model updated with optimistic locking.
+type versionLock struct {
+	column string
+	value  reflect.Value
+}
+
+// bump increments the version field of the model after a successful update.
+func (v *versionLock) bump() {
+	if !v.value.CanSet() {
+		return
+	}
+	switch v.value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.value.SetInt(v.value.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.value.SetUint(v.value.Uint() + 1)
+	}
+}
@@ -0,0 +1,182 @@
+package leopards
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestColumnName(t *testing.T) {
+	type model struct {
+		Ver      int `leopard:"version"`
+		Kind     int `leopard:"type"`
+		Name     int `leopard:"name"`
+		ID       int `leopard:"column:id;primaryKey"`
+		Counter  int `leopard:"primaryKey;autoIncrement" json:"counter"`
+		Email    int `json:"email,omitempty"`
+		Untagged int
+	}
+	want := map[string]string{
+		`Ver`:      `version`,
+		`Kind`:     `type`,
+		`Name`:     `name`,
+		`ID`:       `id`,
+		`Counter`:  `counter`,
+		`Email`:    `email`,
+		`Untagged`: `untagged`,
+	}
+	typ := reflect.TypeOf(model{})
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if got := columnName(f); got != want[f.Name] {
+			t.Errorf("columnName(%s) = %q, want %q", f.Name, got, want[f.Name])
+		}
+	}
+}
+
+func TestTagOptions(t *testing.T) {
+	type model struct {
+		Bare    int `leopard:"version"`
+		Options int `leopard:"column:ver;Version"`
+		Values  int `leopard:"column:name;size:64;index:idx_name"`
+	}
+	tests := []struct {
+		field string
+		want  map[string]string
+	}{
+		{`Bare`, nil},
+		{`Options`, map[string]string{`column`: `ver`, `version`: ``}},
+		{`Values`, map[string]string{`column`: `name`, `size`: `64`, `index`: `idx_name`}},
+	}
+	for _, tt := range tests {
+		f, _ := reflect.TypeOf(model{}).FieldByName(tt.field)
+		if got := tagOptions(f); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tagOptions(%s) = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+}
+
+// A bare `leopard` tag naming an option is a column, and is scanned.
+func TestScanOptionNamedColumn(t *testing.T) {
+	db := openSQLite(t,
+		"CREATE TABLE `item` (`id` integer PRIMARY KEY, `version` integer, `type` text)",
+		"INSERT INTO `item` VALUES (1, 7, 'book')",
+	)
+	type item struct {
+		ID   int    `leopard:"id"`
+		Ver  int    `leopard:"version"`
+		Kind string `leopard:"type"`
+	}
+	var items []item
+	if err := db.Query().From(`item`).Scan(context.Background(), &items); err != nil {
+		t.Fatal(err)
+	}
+	if want := []item{{ID: 1, Ver: 7, Kind: `book`}}; !reflect.DeepEqual(items, want) {
+		t.Errorf("Scan = %+v, want %+v", items, want)
+	}
+}
+
+func TestUpdateModelVersion(t *testing.T) {
+	type user struct {
+		ID      int    `leopard:"column:id;primaryKey"`
+		Name    string `json:"name"`
+		Version int    `leopard:"column:version;version"`
+	}
+	u := Dialect(MySQL).Update(nil, `users`).Model(&user{ID: 1, Name: `a8m`, Version: 2})
+	assertSQL(t, u,
+		"UPDATE `users` SET `name` = ?, `version` = COALESCE(`users`.`version`, 0) + ? WHERE `id` = ? AND `version` = ?",
+		`a8m`, 1, 1, 2,
+	)
+
+	// A bare version tag is the version column, not the option.
+	type legacy struct {
+		ID      int `leopard:"column:id;primaryKey"`
+		Version int `leopard:"version"`
+	}
+	u = Dialect(MySQL).Update(nil, `legacy`).Model(&legacy{ID: 1, Version: 2})
+	assertSQL(t, u, "UPDATE `legacy` SET `version` = ? WHERE `id` = ?", 2, 1)
+}
+
+func TestUpdateStaleObject(t *testing.T) {
+	db := openSQLite(t,
+		"CREATE TABLE `users` (`id` integer PRIMARY KEY, `name` text, `version` integer)",
+		"INSERT INTO `users` VALUES (1, 'a8m', 1)",
+	)
+	type user struct {
+		ID      int    `leopard:"column:id;primaryKey"`
+		Name    string `json:"name"`
+		Version int    `leopard:"column:version;version"`
+	}
+	ctx := context.Background()
+	u := &user{ID: 1, Name: `new`, Version: 1}
+	if _, err := db.Update().Table(`users`).Model(u).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if u.Version != 2 {
+		t.Errorf("Version = %d, want 2", u.Version)
+	}
+	stale := &user{ID: 1, Name: `stale`, Version: 1}
+	_, err := db.Update().Table(`users`).Model(stale).Save(ctx)
+	if !errors.Is(err, ErrStaleObject) {
+		t.Errorf("Save = %v, want ErrStaleObject", err)
+	}
+}
+
+// schemaUser is a model described by a Schema method, as generated by the leopards command.
+type schemaUser struct {
+	ID     int64   `json:"id"`
+	Name   string  `json:"name"`
+	Active bool    `json:"active"`
+	Note   *string `json:"note"`
+}
+
+func (schemaUser) Schema() *TableSchema {
+	return &TableSchema{
+		Name: `users`,
+		Columns: []*ColumnSchema{
+			{Name: `id`, Type: `integer`, AutoIncrement: true},
+			{Name: `name`, Type: `text`},
+			{Name: `active`, Type: `boolean`, Default: `1`},
+			{Name: `note`, Type: `text`, Nullable: true, Default: `'none'`},
+		},
+		PrimaryKey: []string{`id`},
+	}
+}
+
+func TestInsertModelDefaults(t *testing.T) {
+	type user struct {
+		ID     int64   `leopard:"column:id;primaryKey;autoIncrement"`
+		Name   string  `leopard:"column:name;omitempty"`
+		Active bool    `leopard:"column:active;default:1"`
+		Note   *string `leopard:"column:note;default:'none'"`
+	}
+	i := Dialect(MySQL).Insert(nil, `users`).Model(&user{})
+	assertSQL(t, i, "INSERT INTO `users` (`active`) VALUES (?)", false)
+	note := ``
+	i = Dialect(MySQL).Insert(nil, `users`).Model(&user{Name: `a`, Note: &note})
+	assertSQL(t, i, "INSERT INTO `users` (`name`, `active`, `note`) VALUES (?, ?, ?)", `a`, false, &note)
+	i = Dialect(MySQL).Insert(nil, ``).Model(&schemaUser{})
+	assertSQL(t, i, "INSERT INTO `users` (`name`, `active`) VALUES (?, ?)", ``, false)
+
+	db := openSQLite(t, "CREATE TABLE `users` (`id` integer PRIMARY KEY AUTOINCREMENT, `name` text, `active` boolean NOT NULL DEFAULT 1, `note` text DEFAULT 'none')")
+	ctx := context.Background()
+	if _, err := db.Insert().Table(`users`).Model(&user{Name: `a`}).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Insert().Model(&schemaUser{Name: `b`, Active: true}).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Upsert(ctx, &schemaUser{ID: 2, Name: `b`}); err != nil {
+		t.Fatal(err)
+	}
+	var users []schemaUser
+	if err := db.Query().From(`users`).OrderBy(`id`).Scan(ctx, &users); err != nil {
+		t.Fatal(err)
+	}
+	none := `none`
+	want := []schemaUser{{ID: 1, Name: `a`, Note: &none}, {ID: 2, Name: `b`, Note: &none}}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("users = %+v, want %+v", users, want)
+	}
+}
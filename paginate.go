@@ -0,0 +1,187 @@
+package leopards
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Paginate scans the given page (starting from 1) of the selector into dest
+// and returns the total number of rows matching the query. The total is
+// counted by a clone of the selector without its ORDER BY, LIMIT and OFFSET
+// clauses. Queries with GROUP BY, DISTINCT, HAVING or set operations are
+// counted as a sub-query. The page itself is read by another clone, so the
+// selector is left unchanged and can be used for the next page.
+//
+//	users := make([]User, 0, 20)
+//	total, err := db.Query().From(`users`).OrderBy(leopards.Desc(`id`)).Paginate(ctx, 2, 20, &users)
+func (s *Selector) Paginate(ctx context.Context, page, size int, dest any) (total int, err error) {
+	if size <= 0 {
+		return 0, fmt.Errorf("Paginate: invalid page size %d", size)
+	}
+	if page < 1 {
+		page = 1
+	}
+	count, err := s.CountRows(ctx)
+	if err != nil {
+		return 0, err
+	}
+	total = int(count)
+	if total == 0 || (page-1)*size >= total {
+		return total, nil
+	}
+	return total, s.Clone().Limit(size).Offset((page-1)*size).Scan(ctx, dest)
+}
+
+// cursor holds the keyset pagination state of a selector.
+type cursor struct {
+	size    int
+	columns []string // unqualified names of the order columns.
+}
+
+// Cursor applies keyset pagination on the selector. It orders the result by
+// OrderColumns() (which must be all ascending or all descending, and should be
+// unique together), limits it to size rows and, if after is not empty, selects
+// only the rows following the position the cursor points to, using a composite
+// comparison. Use NextCursor on the scanned rows to get the cursor of the next page.
+//
+//	s := db.Query().From(`users`).OrderBy(leopards.Desc(`created_at`), leopards.Desc(`id`)).Cursor(after, 20)
+//	err := s.Scan(ctx, &users)
+//	next, err := s.NextCursor(&users)
+func (s *Selector) Cursor(after string, size int) *Selector {
+	if size <= 0 {
+		s.AddError(fmt.Errorf("Cursor: invalid page size %d", size))
+		return s
+	}
+	order := s.OrderColumns()
+	if len(order) == 0 || len(order) != len(s.order) {
+		s.AddError(errors.New("Cursor: ordering by columns is required"))
+		return s
+	}
+	columns := make([]string, len(order))
+	names := make([]string, len(order))
+	desc := 0
+	for i, c := range order {
+		switch {
+		case strings.HasSuffix(c, ` DESC`):
+			c = strings.TrimSuffix(c, ` DESC`)
+			desc++
+		case strings.HasSuffix(c, ` ASC`):
+			c = strings.TrimSuffix(c, ` ASC`)
+		}
+		columns[i] = c
+		names[i] = cursorName(c)
+	}
+	if desc != 0 && desc != len(order) {
+		s.AddError(errors.New("Cursor: mixed ASC and DESC ordering is not supported"))
+		return s
+	}
+	s.cursor = &cursor{size: size, columns: names}
+	s.Limit(size)
+	if after == `` {
+		return s
+	}
+	values, err := decodeCursor(after, len(columns))
+	if err != nil {
+		s.AddError(err)
+		return s
+	}
+	if desc > 0 {
+		return s.Where(CompositeLT(columns, values...))
+	}
+	return s.Where(CompositeGT(columns, values...))
+}
+
+// NextCursor returns the cursor pointing after the last row of dest, which
+// must be the slice scanned by a selector configured with Cursor. An empty
+// cursor is returned when dest holds less rows than the page size.
+func (s *Selector) NextCursor(dest any) (string, error) {
+	if s.cursor == nil {
+		return ``, errors.New("NextCursor: Cursor was not called on the selector")
+	}
+	v := reflect.Indirect(reflect.ValueOf(dest))
+	if v.Kind() != reflect.Slice {
+		return ``, fmt.Errorf("NextCursor: invalid type: %s. expect slice as argument", v.Type())
+	}
+	if v.Len() < s.cursor.size || v.Len() == 0 {
+		return ``, nil
+	}
+	last := reflect.Indirect(v.Index(v.Len() - 1))
+	if last.Kind() == reflect.Interface {
+		last = reflect.Indirect(last.Elem())
+	}
+	values := make([]any, len(s.cursor.columns))
+	for i, name := range s.cursor.columns {
+		fv, ok := cursorField(last, name)
+		if !ok {
+			return ``, fmt.Errorf("NextCursor: column %q not found in %s", name, last.Type())
+		}
+		values[i] = fv
+	}
+	buf, err := json.Marshal(values)
+	if err != nil {
+		return ``, err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// decodeCursor decodes the values of the given cursor.
+func decodeCursor(c string, n int) ([]any, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(c)
+	if err != nil {
+		return nil, fmt.Errorf("Cursor: invalid cursor: %w", err)
+	}
+	var values []any
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err = dec.Decode(&values); err != nil {
+		return nil, fmt.Errorf("Cursor: invalid cursor: %w", err)
+	}
+	if len(values) != n {
+		return nil, fmt.Errorf("Cursor: invalid cursor: expect %d values, got %d", n, len(values))
+	}
+	for i, v := range values {
+		n, ok := v.(json.Number)
+		if !ok {
+			continue
+		}
+		if iv, err := n.Int64(); err == nil {
+			values[i] = iv
+		} else if fv, err := n.Float64(); err == nil {
+			values[i] = fv
+		}
+	}
+	return values, nil
+}
+
+// cursorName returns the unqualified and unquoted name of an order column.
+func cursorName(c string) string {
+	if i := strings.LastIndex(c, `.`); i >= 0 {
+		c = c[i+1:]
+	}
+	return strings.Trim(c, "`\"[]")
+}
+
+// cursorField returns the value of the given column from a scanned row.
+func cursorField(row reflect.Value, name string) (any, bool) {
+	switch row.Kind() {
+	case reflect.Map:
+		v := row.MapIndex(reflect.ValueOf(name))
+		if !v.IsValid() {
+			return nil, false
+		}
+		return v.Interface(), true
+	case reflect.Struct:
+		for _, f := range modelFields(row.Type()) {
+			if f.column == name || strings.EqualFold(f.column, name) || f.name == name {
+				return row.FieldByIndex(f.index).Interface(), true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,142 @@
+package leopards
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type pageUser struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func openUsers(t *testing.T) *DB {
+	t.Helper()
+	return openSQLite(t,
+		"CREATE TABLE `users` (`id` integer PRIMARY KEY, `name` text, `age` integer)",
+		"INSERT INTO `users` VALUES (1, 'a', 10), (2, 'b', 20), (3, 'c', 20), (4, 'd', 30), (5, 'e', 40)",
+	)
+}
+
+func TestPaginate(t *testing.T) {
+	db := openUsers(t)
+	ctx := context.Background()
+	tests := []struct {
+		page, size int
+		ids        []int64
+	}{
+		{1, 2, []int64{5, 4}},
+		{2, 2, []int64{3, 2}},
+		{3, 2, []int64{1}},
+		{4, 2, nil},
+		{0, 10, []int64{5, 4, 3, 2, 1}},
+	}
+	for _, tt := range tests {
+		var users []pageUser
+		total, err := db.Query().From(`users`).OrderBy(Desc(`id`)).Paginate(ctx, tt.page, tt.size, &users)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 5 {
+			t.Errorf("page %d: total = %d, want 5", tt.page, total)
+		}
+		var ids []int64
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.ids) {
+			t.Errorf("page %d: ids = %v, want %v", tt.page, ids, tt.ids)
+		}
+	}
+
+	// The selector is not modified and can be reused for every page.
+	s := db.Query().From(`users`).OrderBy(Desc(`id`))
+	for page, want := range [][]int64{{5, 4}, {3, 2}} {
+		var users []pageUser
+		if _, err := s.Paginate(ctx, page+1, 2, &users); err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 2 || users[0].ID != want[0] || users[1].ID != want[1] {
+			t.Errorf("reused selector, page %d: users = %v, want ids %v", page+1, users, want)
+		}
+	}
+	assertSQL(t, s, "SELECT * FROM `users` ORDER BY `id` DESC")
+
+	// Grouped queries are counted as a sub-query.
+	var ages []struct {
+		Age int `json:"age"`
+	}
+	total, err := db.Query().Select(`age`).From(`users`).GroupBy(`age`).OrderBy(`age`).Paginate(ctx, 1, 2, &ages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 || len(ages) != 2 || ages[0].Age != 10 {
+		t.Errorf("grouped: total = %d, ages = %v", total, ages)
+	}
+}
+
+func TestCursorSQL(t *testing.T) {
+	s := Dialect(Postgres).Select(nil).From(`users`).OrderBy(Desc(`created_at`), Desc(`id`)).Cursor(``, 20)
+	assertSQL(t, s, `SELECT * FROM "users" ORDER BY "created_at" DESC, "id" DESC LIMIT 20`)
+
+	next, err := s.NextCursor(&[]map[string]any{{`created_at`: `2024-01-02`, `id`: 7}})
+	if err != nil || next != `` {
+		t.Fatalf("NextCursor of a partial page = %q, %v, want empty", next, err)
+	}
+	page := make([]map[string]any, 20)
+	for i := range page {
+		page[i] = map[string]any{`created_at`: `2024-01-02`, `id`: int64(20 - i)}
+	}
+	next, err = s.NextCursor(&page)
+	if err != nil || next == `` {
+		t.Fatalf("NextCursor = %q, %v", next, err)
+	}
+	s = Dialect(Postgres).Select(nil).From(`users`).OrderBy(Desc(`created_at`), Desc(`id`)).Cursor(next, 20)
+	assertSQL(t, s,
+		`SELECT * FROM "users" WHERE ("created_at", "id") < ($1, $2) ORDER BY "created_at" DESC, "id" DESC LIMIT 20`,
+		`2024-01-02`, int64(1),
+	)
+
+	for _, s := range []*Selector{
+		Dialect(MySQL).Select(nil).From(`users`).Cursor(``, 10),
+		Dialect(MySQL).Select(nil).From(`users`).OrderBy(`a`, Desc(`b`)).Cursor(``, 10),
+		Dialect(MySQL).Select(nil).From(`users`).OrderBy(`a`).Cursor(``, 0),
+		Dialect(MySQL).Select(nil).From(`users`).OrderBy(`a`).Cursor(`!`, 10),
+	} {
+		if _, _, err := s.ToSQL(); err == nil {
+			t.Errorf("Cursor: expect an error for %v", s.order)
+		}
+	}
+}
+
+func TestCursorPages(t *testing.T) {
+	db := openUsers(t)
+	ctx := context.Background()
+	var (
+		after string
+		ids   []int64
+	)
+	for i := 0; i < 5; i++ {
+		var users []pageUser
+		s := db.Query().From(`users`).OrderBy(`id`).Cursor(after, 2)
+		if err := s.Scan(ctx, &users); err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		next, err := s.NextCursor(&users)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next == `` {
+			break
+		}
+		after = next
+	}
+	if want := []int64{1, 2, 3, 4, 5}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,43 @@
+package leopards
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Cloned predicates (Selector.Clone, used by CountRows and Paginate) run the
+// callbacks of the original predicates on the builder of the clone.
+func TestPredicateClone(t *testing.T) {
+	tests := []struct {
+		dialect string
+		p       *Predicate
+		want    string
+		args    []any
+	}{
+		{MySQL, LT(`a`, 1), "SELECT * FROM `users` WHERE `a` < ?", []any{1}},
+		{MySQL, LTE(`a`, 1), "SELECT * FROM `users` WHERE `a` <= ?", []any{1}},
+		{MySQL, GT(`a`, 1), "SELECT * FROM `users` WHERE `a` > ?", []any{1}},
+		{MySQL, GTE(`a`, 1), "SELECT * FROM `users` WHERE `a` >= ?", []any{1}},
+		{MySQL, Between(`a`, 1, 2), "SELECT * FROM `users` WHERE `a` BETWEEN ? AND ?", []any{1, 2}},
+		{Postgres, GT(`a`, 1), `SELECT * FROM "users" WHERE "a" > $1`, []any{1}},
+		{SQLite, Contains(`name`, `a%b`), "SELECT * FROM `users` WHERE `name` LIKE ? ESCAPE ?", []any{`%a\%b%`, `\`}},
+		{SQLite, HasPrefix(`name`, `a_`), "SELECT * FROM `users` WHERE `name` LIKE ? ESCAPE ?", []any{`a\_%`, `\`}},
+		{SQLite, ContainsFold(`name`, `A_b`), "SELECT * FROM `users` WHERE LOWER(`name`) LIKE ? ESCAPE ?", []any{`%a\_b%`, `\`}},
+		{SQLite, ColumnsHasPrefix(`name`, `prefix`), "SELECT * FROM `users` WHERE `name` LIKE (REPLACE(REPLACE(`prefix`, '_', '\\_'), '%', '\\%') || '%') ESCAPE ?", []any{`\`}},
+		{Postgres, ColumnsHasPrefix(`name`, `prefix`), `SELECT * FROM "users" WHERE "name" LIKE (REPLACE(REPLACE("prefix", '_', '\_'), '%', '\%') || '%')`, nil},
+	}
+	for _, tt := range tests {
+		s := Dialect(tt.dialect).Select(nil).From(`users`).Where(tt.p)
+		for _, s := range []*Selector{s, s.Clone()} {
+			query, args := s.query()
+			if query != tt.want {
+				t.Errorf("query:\n got: %s\nwant: %s", query, tt.want)
+			}
+			if len(args) > 0 || len(tt.args) > 0 {
+				if !reflect.DeepEqual(args, tt.args) {
+					t.Errorf("%s: args = %#v, want %#v", tt.want, args, tt.args)
+				}
+			}
+		}
+	}
+}
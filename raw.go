@@ -0,0 +1,227 @@
+package leopards
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RawQuery is a raw SQL statement executed through the DB, for
+// queries the builders can't express. Interceptors registered with
+// InterceptorsRaw may change its Statement and Args before execution.
+type RawQuery struct {
+	Statement string // SQL statement.
+	Args      []any  // statement arguments.
+
+	ctx    context.Context
+	err    error
+	driver *DB
+}
+
+// Raw returns a raw SQL query. The arguments are either positional arguments
+// using the placeholders of the dialect, or named arguments (`:name` or `@name`)
+// bound from a map, a struct (with the tag rules of the columns) or sql.Named
+// arguments. Named arguments are rewritten to the placeholders of the dialect.
+//
+//	db.Raw(ctx, "SELECT * FROM users WHERE age > ?", 30).Scan(&users)
+//	db.Raw(ctx, "SELECT * FROM users WHERE age > :age", map[string]any{"age": 30}).Scan(&users)
+func (b *DB) Raw(ctx context.Context, statement string, args ...any) *RawQuery {
+	r := &RawQuery{Statement: statement, Args: args, ctx: ctx, driver: b}
+	r.Statement, r.Args, r.err = b.bindNamed(statement, args)
+	return r
+}
+
+// Exec executes a raw SQL statement. See Raw for the supported arguments.
+//
+//	db.Exec(ctx, "UPDATE users SET age = age + 1 WHERE id = :id", sql.Named("id", 1))
+func (b *DB) Exec(ctx context.Context, statement string, args ...any) (sql.Result, error) {
+	return b.Raw(ctx, statement, args...).Exec()
+}
+
+// Scan executes the query and scans its rows into dest, like Selector.Scan.
+func (r *RawQuery) Scan(dest any) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	for _, iter := range r.driver.beforeRaw {
+		iter(r)
+	}
+
+	rows, err := r.driver.queryContext(r.ctx, r.Statement, r.Args)
+	if err != nil {
+		return err
+	}
+
+	err = r.driver.Scan(rows, dest)
+	if err != nil {
+		_ = rows.Close()
+	}
+
+	for _, iter := range r.driver.afterRaw {
+		iter(r, dest)
+	}
+
+	return err
+}
+
+// Exec executes the statement without returning any rows.
+func (r *RawQuery) Exec() (sql.Result, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	for _, iter := range r.driver.beforeRaw {
+		iter(r)
+	}
+
+	res, err := r.driver.execContext(r.ctx, r.Statement, r.Args)
+
+	for _, iter := range r.driver.afterRaw {
+		iter(r, res)
+	}
+
+	return res, err
+}
+
+// bindNamed rewrites the named parameters of the statement to the placeholders
+// of the dialect, and returns their arguments. Statements with positional
+// arguments are returned as is.
+func (b *DB) bindNamed(statement string, args []any) (string, []any, error) {
+	lookup := b.namedArgs(args)
+	if lookup == nil {
+		return statement, args, nil
+	}
+	var (
+		sb    strings.Builder
+		bound []any
+		d     = dialectOf(b.dialect)
+	)
+	for i := 0; i < len(statement); i++ {
+		c := statement[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for ; j < len(statement); j++ {
+				if statement[j] != c {
+					continue
+				}
+				// Quotes are escaped by doubling them.
+				if j+1 < len(statement) && statement[j+1] == c {
+					j++
+					continue
+				}
+				break
+			}
+			if j >= len(statement) {
+				j = len(statement) - 1
+			}
+			sb.WriteString(statement[i : j+1])
+			i = j
+		case c == '-' && strings.HasPrefix(statement[i:], "--"):
+			j := strings.IndexByte(statement[i:], '\n')
+			if j == -1 {
+				j = len(statement) - i - 1
+			}
+			sb.WriteString(statement[i : i+j+1])
+			i += j
+		case c == '/' && strings.HasPrefix(statement[i:], "/*"):
+			j := strings.Index(statement[i:], "*/")
+			if j == -1 {
+				j = len(statement) - i - 2
+			}
+			sb.WriteString(statement[i : i+j+2])
+			i += j + 1
+		// Skip casts (::int), system variables (@@var) and qualified names (a:b).
+		case (c == ':' || c == '@') && i+1 < len(statement) && isNameStart(statement[i+1]) &&
+			(i == 0 || statement[i-1] != c && !isNamePart(statement[i-1])):
+			j := i + 1
+			for j < len(statement) && isNamePart(statement[j]) {
+				j++
+			}
+			name := statement[i+1 : j]
+			v, ok := lookup(name)
+			if !ok {
+				return "", nil, fmt.Errorf("Raw: missing value for named parameter %q", name)
+			}
+			bound = append(bound, v)
+			sb.WriteString(d.Placeholder(len(bound)))
+			i = j - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	// No named parameters, the arguments are positional.
+	if len(bound) == 0 {
+		return statement, args, nil
+	}
+	return sb.String(), bound, nil
+}
+
+// namedArgs returns the lookup function of named arguments, or
+// nil if the arguments are positional.
+func (b *DB) namedArgs(args []any) func(string) (any, bool) {
+	if len(args) == 0 {
+		return nil
+	}
+	named := make(map[string]any, len(args))
+	for _, arg := range args {
+		n, ok := arg.(sql.NamedArg)
+		if !ok {
+			break
+		}
+		named[n.Name] = n.Value
+	}
+	if len(named) == len(args) {
+		return func(name string) (any, bool) {
+			v, ok := named[name]
+			return v, ok
+		}
+	}
+	if len(args) != 1 {
+		return nil
+	}
+	rv := reflect.ValueOf(args[0])
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	switch typ := rv.Type(); {
+	case typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String:
+		return func(name string) (any, bool) {
+			v := rv.MapIndex(reflect.ValueOf(name).Convert(typ.Key()))
+			if !v.IsValid() {
+				return nil, false
+			}
+			return v.Interface(), true
+		}
+	case typ.Kind() == reflect.Struct && !isScalar(typ) && !typ.Implements(valuerType) && !reflect.PointerTo(typ).Implements(valuerType):
+		names := b.parseEmbed(make(map[string][]int, typ.NumField()), typ, []int{}, 0)
+		return func(name string) (any, bool) {
+			idx, ok := names[name]
+			if !ok {
+				idx, ok = names[strings.ToLower(name)]
+			}
+			if !ok {
+				return nil, false
+			}
+			return rv.FieldByIndex(idx).Interface(), true
+		}
+	}
+	return nil
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9'
+}
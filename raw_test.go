@@ -0,0 +1,154 @@
+package leopards
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestBindNamed(t *testing.T) {
+	type filter struct {
+		Name string `json:"name"`
+		Age  int    `leopard:"column:min_age"`
+	}
+	tests := []struct {
+		dialect   string
+		statement string
+		args      []any
+		want      string
+		wantArgs  []any
+	}{
+		{
+			dialect:   MySQL,
+			statement: `SELECT * FROM users WHERE name = :name AND age > :age OR nick = :name`,
+			args:      []any{map[string]any{`name`: `a`, `age`: 30}},
+			want:      `SELECT * FROM users WHERE name = ? AND age > ? OR nick = ?`,
+			wantArgs:  []any{`a`, 30, `a`},
+		},
+		{
+			dialect:   Postgres,
+			statement: `SELECT * FROM users WHERE name = @name AND age > :min_age`,
+			args:      []any{&filter{Name: `a`, Age: 30}},
+			want:      `SELECT * FROM users WHERE name = $1 AND age > $2`,
+			wantArgs:  []any{`a`, 30},
+		},
+		{
+			dialect:   SQLServer,
+			statement: `UPDATE users SET age = age + 1 WHERE id = :id`,
+			args:      []any{sql.Named(`id`, 1)},
+			want:      `UPDATE users SET age = age + 1 WHERE id = @p1`,
+			wantArgs:  []any{1},
+		},
+		{
+			// Quotes, comments, casts, system variables and qualified names are kept.
+			dialect:   Postgres,
+			statement: "SELECT ':a', \"@b\", 'it''s :c', created_at::date, @@version, x:y /* :d */ FROM t -- :e\nWHERE id = :id",
+			args:      []any{map[string]int{`id`: 1}},
+			want:      "SELECT ':a', \"@b\", 'it''s :c', created_at::date, @@version, x:y /* :d */ FROM t -- :e\nWHERE id = $1",
+			wantArgs:  []any{1},
+		},
+		{
+			// Positional arguments are passed as is.
+			dialect:   Postgres,
+			statement: `SELECT * FROM users WHERE id = $1 AND name = $2`,
+			args:      []any{1, `a`},
+			want:      `SELECT * FROM users WHERE id = $1 AND name = $2`,
+			wantArgs:  []any{1, `a`},
+		},
+		{
+			// A single map without named parameters is a positional argument.
+			dialect:   MySQL,
+			statement: `INSERT INTO t (data) VALUES (?)`,
+			args:      []any{map[string]any{`a`: 1}},
+			want:      `INSERT INTO t (data) VALUES (?)`,
+			wantArgs:  []any{map[string]any{`a`: 1}},
+		},
+	}
+	for _, tt := range tests {
+		db := &DB{dialect: tt.dialect}
+		statement, args, err := db.bindNamed(tt.statement, tt.args)
+		if err != nil {
+			t.Errorf("%s: %v", tt.statement, err)
+			continue
+		}
+		if statement != tt.want {
+			t.Errorf("statement:\n got: %s\nwant: %s", statement, tt.want)
+		}
+		assertArgs(t, args, tt.wantArgs)
+	}
+
+	db := &DB{dialect: MySQL}
+	if _, _, err := db.bindNamed(`SELECT * FROM users WHERE id = :id`, []any{map[string]any{`name`: `a`}}); err == nil {
+		t.Error("bindNamed: expect an error for a missing named parameter")
+	}
+}
+
+func TestRaw(t *testing.T) {
+	db := openSQLite(t, "CREATE TABLE `users` (`id` integer PRIMARY KEY, `name` text, `age` integer)")
+	ctx := context.Background()
+	var statements []string
+	db.InterceptorsRaw(func(r *RawQuery) {
+		statements = append(statements, r.Statement)
+	})
+	var afterRaw int
+	db.InterceptorsAfterRaw(func(*RawQuery, any) { afterRaw++ })
+
+	if _, err := db.Exec(ctx, "INSERT INTO users (name, age) VALUES (:name, :age)", map[string]any{`name`: `a`, `age`: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, "INSERT INTO users (name, age) VALUES (?, ?)", `b`, 20); err != nil {
+		t.Fatal(err)
+	}
+	type user struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	var users []user
+	if err := db.Raw(ctx, "SELECT * FROM users WHERE age >= @age ORDER BY id", sql.Named(`age`, 10)).Scan(&users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Name != `a` || users[1].Age != 20 {
+		t.Errorf("users = %+v", users)
+	}
+	var count int
+	if err := db.Raw(ctx, "SELECT COUNT(*) FROM users").Scan(&count); err != nil || count != 2 {
+		t.Errorf("count = %d, %v, want 2", count, err)
+	}
+	want := []string{
+		"INSERT INTO users (name, age) VALUES (?, ?)",
+		"INSERT INTO users (name, age) VALUES (?, ?)",
+		"SELECT * FROM users WHERE age >= ? ORDER BY id",
+		"SELECT COUNT(*) FROM users",
+	}
+	if len(statements) != len(want) || afterRaw != len(want) {
+		t.Fatalf("statements = %q, %d after interceptors, want %q", statements, afterRaw, want)
+	}
+	for i := range want {
+		if statements[i] != want[i] {
+			t.Errorf("statement %d = %s, want %s", i, statements[i], want[i])
+		}
+	}
+
+	// Raw statements run in the transaction of the DB.
+	tx, err := db.TX(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM users"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Raw(ctx, "SELECT COUNT(*) FROM users").Scan(&count); err != nil || count != 0 {
+		t.Errorf("count in transaction = %d, %v, want 0", count, err)
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Raw(ctx, "SELECT COUNT(*) FROM users").Scan(&count); err != nil || count != 2 {
+		t.Errorf("count after rollback = %d, %v, want 2", count, err)
+	}
+
+	if err := db.Raw(ctx, "SELECT * FROM users WHERE id = :id", map[string]any{}).Scan(&users); err == nil {
+		t.Error("Scan: expect an error for a missing named parameter")
+	}
+}
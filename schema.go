@@ -0,0 +1,66 @@
+package leopards
+
+// TableSchema describes a table. It is generated by the leopards command
+// from the database, and returned by the Schema method of the generated
+// structs:
+//
+//	func (UserInfo) Schema() *leopards.TableSchema { return UserInfoSchema }
+//
+// Models with a Schema method are inserted without their zero-valued
+// auto-increment columns and the nil pointers of the columns with a
+// default value, so the database fills them.
+type TableSchema struct {
+	Name        string              // table name.
+	Columns     []*ColumnSchema     // columns in ordinal order.
+	PrimaryKey  []string            // primary key columns.
+	Indexes     []*IndexSchema      // indexes, including unique indexes.
+	ForeignKeys []*ForeignKeySchema // foreign keys.
+}
+
+// ColumnSchema describes a column of a table.
+type ColumnSchema struct {
+	Name          string // column name.
+	Type          string // database type, e.g. varchar(255).
+	Nullable      bool   // NULL values are allowed.
+	Unique        bool   // unique column.
+	AutoIncrement bool   // filled by the database (auto_increment, serial, identity).
+	Default       string // default value expression, or "" if the column has none.
+}
+
+// IndexSchema describes an index of a table.
+type IndexSchema struct {
+	Name    string   // index name.
+	Unique  bool     // unique index.
+	Columns []string // indexed columns.
+}
+
+// ForeignKeySchema describes a foreign key of a table.
+type ForeignKeySchema struct {
+	Name       string   // constraint name.
+	Columns    []string // columns of the table.
+	RefTable   string   // referenced table.
+	RefColumns []string // referenced columns.
+	OnUpdate   string   // ON UPDATE action, e.g. CASCADE.
+	OnDelete   string   // ON DELETE action, e.g. SET NULL.
+}
+
+// Column returns the column with the given name, or nil.
+func (t *TableSchema) Column(name string) *ColumnSchema {
+	if t == nil {
+		return nil
+	}
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// modelTableSchema returns the schema of the model, if it has a Schema method.
+func modelTableSchema(v any) *TableSchema {
+	if s, ok := v.(interface{ Schema() *TableSchema }); ok {
+		return s.Schema()
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package leopards
+
+import (
+	"context"
+	"fmt"
+)
+
+// ScopeFunc resolves the value of a global scope from the context of the
+// executing statement. The boolean result reports whether the value was found.
+type ScopeFunc func(ctx context.Context) (any, bool)
+
+// ContextScope returns a ScopeFunc reading the scope value from ctx.Value(key).
+//
+//	db.Scope(`tenant`, `tenant_id`, leopards.ContextScope(tenantKey{}))
+func ContextScope(key any) ScopeFunc {
+	return func(ctx context.Context) (any, bool) {
+		v := ctx.Value(key)
+		return v, v != nil
+	}
+}
+
+// scope is a named global scope registered on the DB.
+type scope struct {
+	name   string
+	column string
+	value  ScopeFunc
+}
+
+// scopeValue is a global scope resolved for one statement execution.
+type scopeValue struct {
+	column string
+	value  any
+}
+
+// scoping records the global scopes a builder opted out of.
+type scoping struct {
+	all   bool
+	names []string
+}
+
+// unscope adds the given scope names to the opt-out list, or
+// opts out of all scopes if no names were given.
+func (s *scoping) unscope(names []string) {
+	if len(names) == 0 {
+		s.all = true
+		return
+	}
+	s.names = append(s.names, names...)
+}
+
+// skips reports if the scope with the given name should not be applied.
+func (s scoping) skips(name string) bool {
+	if s.all {
+		return true
+	}
+	for _, n := range s.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Scope registers a named global scope. The scope is ANDed as `column = value`
+// into the WHERE clause of every Selector, UpdateBuilder and DeleteBuilder
+// executed through the DB, and set as a column on every InsertBuilder.
+// The value is resolved from the context passed to Scan/Save/Exec, and a
+// statement fails if the value is missing, unless the builder opted out
+// with Unscoped. Registering a scope with an existing name replaces it.
+//
+//	db.Scope(`tenant`, `tenant_id`, leopards.ContextScope(tenantKey{}))
+//
+// Note: scopes apply to the executed statement only, not to sub-queries.
+func (b *DB) Scope(name, column string, value ScopeFunc) {
+	for i := range b.scopes {
+		if b.scopes[i].name == name {
+			b.scopes[i] = scope{name: name, column: column, value: value}
+			return
+		}
+	}
+	b.scopes = append(b.scopes, scope{name: name, column: column, value: value})
+}
+
+// resolveScopes resolves the values of the global scopes that apply to a builder.
+func (b *DB) resolveScopes(ctx context.Context, skip scoping) ([]scopeValue, error) {
+	if b == nil || len(b.scopes) == 0 {
+		return nil, nil
+	}
+	values := make([]scopeValue, 0, len(b.scopes))
+	for _, s := range b.scopes {
+		if skip.skips(s.name) {
+			continue
+		}
+		v, ok := s.value(ctx)
+		if !ok {
+			return nil, fmt.Errorf("scope %q: missing value for column %q in context", s.name, s.column)
+		}
+		values = append(values, scopeValue{column: s.column, value: v})
+	}
+	return values, nil
+}
+
+// scopedWhere returns the where predicate ANDed with the resolved scopes.
+// The qualify function (if not nil) formats the scope column.
+func scopedWhere(where *Predicate, scoped []scopeValue, qualify func(string) string) *Predicate {
+	if len(scoped) == 0 {
+		return where
+	}
+	preds := make([]*Predicate, 0, len(scoped)+1)
+	if where != nil {
+		preds = append(preds, where)
+	}
+	for _, sv := range scoped {
+		column := sv.column
+		if qualify != nil {
+			column = qualify(column)
+		}
+		preds = append(preds, EQ(column, sv.value))
+	}
+	return And(preds...)
+}
+
+// Unscoped disables the given global scopes for this statement,
+// or all of them if no names were given.
+func (s *Selector) Unscoped(names ...string) *Selector {
+	s.unscoped.unscope(names)
+	return s
+}
+
+// Unscoped disables the given global scopes for this statement,
+// or all of them if no names were given.
+func (i *InsertBuilder) Unscoped(names ...string) *InsertBuilder {
+	i.unscoped.unscope(names)
+	return i
+}
+
+// Unscoped disables the given global scopes for this statement,
+// or all of them if no names were given.
+func (u *UpdateBuilder) Unscoped(names ...string) *UpdateBuilder {
+	u.unscoped.unscope(names)
+	return u
+}
+
+// Unscoped disables the given global scopes for this statement,
+// or all of them if no names were given.
+func (d *DeleteBuilder) Unscoped(names ...string) *DeleteBuilder {
+	d.unscoped.unscope(names)
+	return d
+}
@@ -0,0 +1,72 @@
+package leopards
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type tenantKey struct{}
+
+func TestScopes(t *testing.T) {
+	db := openSQLite(t,
+		"CREATE TABLE `user` (`id` integer PRIMARY KEY, `name` text, `tenant_id` integer)",
+		"INSERT INTO `user` VALUES (1, 'a', 1), (2, 'b', 2)",
+	)
+	db.Scope(`tenant`, `tenant_id`, ContextScope(tenantKey{}))
+	var statements []string
+	record := func(q interface {
+		ToSQL() (string, []any, error)
+	}) {
+		statement, _, _ := q.ToSQL()
+		statements = append(statements, statement)
+	}
+	db.InterceptorsQuery(func(s *Selector) { record(s) })
+	db.InterceptorsInsert(func(i *InsertBuilder) { record(i) })
+	db.InterceptorsUpdate(func(u *UpdateBuilder) { record(u) })
+	db.InterceptorsDelete(func(d *DeleteBuilder) { record(d) })
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, 1)
+	var names []string
+	if err := db.Query().Select(`name`).From(`user`).OrderBy(`id`).Scan(ctx, &names); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`a`}; !reflect.DeepEqual(names, want) {
+		t.Errorf("scoped names = %v, want %v", names, want)
+	}
+	if _, err := db.Insert().Table(`user`).Columns(`id`, `name`).Values(3, `c`).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Update().Table(`user`).Set(`name`, `x`).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Delete().Table(`user`).Where(EQ(`id`, 2)).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"SELECT `name` FROM `user` WHERE `user`.`tenant_id` = ? ORDER BY `id`",
+		"INSERT INTO `user` (`id`, `name`, `tenant_id`) VALUES (?, ?, ?)",
+		"UPDATE `user` SET `name` = ? WHERE `tenant_id` = ?",
+		"DELETE FROM `user` WHERE `id` = ? AND `tenant_id` = ?",
+	}
+	if !reflect.DeepEqual(statements, want) {
+		t.Errorf("statements:\n got: %q\nwant: %q", statements, want)
+	}
+
+	// Opting out of the scope, the rows of all tenants are visible.
+	names = nil
+	if err := db.Query().Select(`name`).From(`user`).OrderBy(`id`).Unscoped(`tenant`).Scan(ctx, &names); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`x`, `b`, `x`}; !reflect.DeepEqual(names, want) {
+		t.Errorf("unscoped names = %v, want %v", names, want)
+	}
+
+	// A missing scope value fails the statement.
+	if err := db.Query().From(`user`).Scan(context.Background(), &names); err == nil {
+		t.Error("Scan without tenant: expect an error")
+	}
+	if _, err := db.Delete().Table(`user`).Exec(context.Background()); err == nil {
+		t.Error("Delete without tenant: expect an error")
+	}
+}
@@ -15,10 +15,12 @@ import (
 
 // Dialect names for external usage.
 const (
-	MySQL    = "mysql"
-	SQLite   = "sqlite3"
-	Postgres = "postgres"
-	Gremlin  = "gremlin"
+	MySQL     = "mysql"
+	MySQL8    = "mysql8" // MySQL 8.0.19+, with the row alias of upserts.
+	SQLite    = "sqlite3"
+	Postgres  = "postgres"
+	SQLServer = "sqlserver"
+	Gremlin   = "gremlin"
 )
 
 // Querier wraps the basic Query method that is implemented
@@ -34,6 +36,61 @@ type querierErr interface {
 	Err() error
 }
 
+// Renderer is implemented by all builders in this package. It exposes the
+// SQL representation of a statement (or expression) outside of the package.
+type Renderer interface {
+	// ToSQL returns the query representation of the element, its
+	// arguments and the errors occurred while building it.
+	ToSQL() (string, []any, error)
+}
+
+// Expression is implemented by user-defined expressions. Use ExprOf
+// to pass an Expression wherever a Querier is expected.
+type Expression interface {
+	// WriteSQL writes the expression to the given builder, which is
+	// configured with the dialect and arguments count of the statement.
+	WriteSQL(b *Builder)
+}
+
+// ExprOf returns a Querier writing the given user-defined expression.
+// Predicates can be created from an Expression with P(e.WriteSQL).
+//
+//	type jsonContains struct{ col, v string }
+//
+//	func (j jsonContains) WriteSQL(b *leopards.Builder) {
+//		b.WriteString("JSON_CONTAINS(").Ident(j.col).Comma().Arg(j.v).WriteByte(')')
+//	}
+//
+//	db.Query().From(`users`).
+//		AppendSelectExprAs(leopards.ExprOf(jsonContains{`tags`, `"go"`}), `is_go`).
+//		Where(leopards.P(jsonContains{`tags`, `"sql"`}.WriteSQL))
+func ExprOf(e Expression) Querier {
+	return ExprFunc(e.WriteSQL)
+}
+
+// toSQL renders the given Querier, failing on the errors added to
+// its builder during construction or rendering.
+func toSQL(q Querier) (string, []any, error) {
+	if st, ok := q.(state); ok {
+		if err := checkDialect(st.Dialect()); err != nil {
+			return "", nil, err
+		}
+	}
+	qe, ok := q.(querierErr)
+	if ok {
+		if err := qe.Err(); err != nil {
+			return "", nil, err
+		}
+	}
+	query, args := q.query()
+	if ok {
+		if err := qe.Err(); err != nil {
+			return "", nil, err
+		}
+	}
+	return query, args, nil
+}
+
 // ColumnBuilder is a builder for column definition in table creation.
 type ColumnBuilder struct {
 	Builder
@@ -79,9 +136,10 @@ func (c *ColumnBuilder) Check(check func(*Builder)) *ColumnBuilder {
 
 // Query returns query representation of a Column.
 func (c *ColumnBuilder) query() (string, []any) {
+	c.reset()
 	c.Ident(c.name)
 	if c.typ != "" {
-		if c.postgres() && c.modify {
+		if c.modify {
 			c.WriteString(" TYPE")
 		}
 		c.Pad().WriteString(c.typ)
@@ -103,6 +161,12 @@ func (c *ColumnBuilder) query() (string, []any) {
 	return c.String(), c.args
 }
 
+// ToSQL returns the SQL representation of the column definition, its arguments
+// and the errors occurred while building it.
+func (c *ColumnBuilder) ToSQL() (string, []any, error) {
+	return toSQL(c)
+}
+
 // TableBuilder is a query builder for `CREATE TABLE` statement.
 type TableBuilder struct {
 	Builder
@@ -115,6 +179,7 @@ type TableBuilder struct {
 	primary     []string         // primary key.
 	constraints []Querier        // foreign keys and indices.
 	checks      []func(*Builder) // check constraints.
+	model       reflect.Type     // struct type the columns are derived from.
 }
 
 // CreateTable returns a query builder for the `CREATE TABLE` statement.
@@ -207,17 +272,22 @@ func (t *TableBuilder) Options(s string) *TableBuilder {
 //	(table definition)
 //	[charset and collation]
 func (t *TableBuilder) query() (string, []any) {
+	t.reset()
 	t.WriteString("CREATE TABLE ")
 	if t.exists {
 		t.WriteString("IF NOT EXISTS ")
 	}
 	t.Ident(t.name)
+	columns, primary := t.columns, t.primary
+	if t.model != nil {
+		columns, primary = t.modelColumns()
+	}
 	t.Wrap(func(b *Builder) {
-		b.JoinComma(t.columns...)
-		if len(t.primary) > 0 {
+		b.JoinComma(columns...)
+		if len(primary) > 0 {
 			b.Comma().WriteString("PRIMARY KEY")
 			b.Wrap(func(b *Builder) {
-				b.IdentComma(t.primary...)
+				b.IdentComma(primary...)
 			})
 		}
 		if len(t.constraints) > 0 {
@@ -239,6 +309,12 @@ func (t *TableBuilder) query() (string, []any) {
 	return t.String(), t.args
 }
 
+// ToSQL returns the SQL representation of the `CREATE TABLE` statement, its arguments
+// and the errors occurred while building it.
+func (t *TableBuilder) ToSQL() (string, []any, error) {
+	return toSQL(t)
+}
+
 // DescribeBuilder is a query builder for `DESCRIBE` statement.
 type DescribeBuilder struct {
 	Builder
@@ -252,11 +328,18 @@ func Describe(name string) *DescribeBuilder { return &DescribeBuilder{name: name
 
 // query returns query representation of a `DESCRIBE` statement.
 func (t *DescribeBuilder) query() (string, []any) {
+	t.reset()
 	t.WriteString("DESCRIBE ")
 	t.Ident(t.name)
 	return t.String(), nil
 }
 
+// ToSQL returns the SQL representation of the `DESCRIBE` statement, its arguments
+// and the errors occurred while building it.
+func (t *DescribeBuilder) ToSQL() (string, []any, error) {
+	return toSQL(t)
+}
+
 // TableAlter is a query builder for `ALTER TABLE` statement.
 type TableAlter struct {
 	Builder
@@ -275,14 +358,18 @@ func AlterTable(name string) *TableAlter { return &TableAlter{name: name} }
 
 // AddColumn appends the `ADD COLUMN` clause to the given `ALTER TABLE` statement.
 func (t *TableAlter) AddColumn(c *ColumnBuilder) *TableAlter {
-	t.Queries = append(t.Queries, &Wrapper{"ADD COLUMN %s", c})
+	clause := t.features().AddColumn
+	if clause == "" {
+		clause = "ADD COLUMN"
+	}
+	t.Queries = append(t.Queries, &Wrapper{clause + " %s", c})
 	return t
 }
 
 // ModifyColumn appends the `MODIFY/ALTER COLUMN` clause to the given `ALTER TABLE` statement.
 func (t *TableAlter) ModifyColumn(c *ColumnBuilder) *TableAlter {
 	switch {
-	case t.postgres():
+	case t.features().AlterColumnType:
 		c.modify = true
 		t.Queries = append(t.Queries, &Wrapper{"ALTER COLUMN %s", c})
 	default:
@@ -369,6 +456,7 @@ func (t *TableAlter) DropForeignKey(ident string) *TableAlter {
 //	ALTER TABLE name
 //		[alter_specification]
 func (t *TableAlter) query() (string, []any) {
+	t.reset()
 	t.WriteString("ALTER TABLE ")
 	t.Ident(t.name)
 	t.Pad()
@@ -376,6 +464,12 @@ func (t *TableAlter) query() (string, []any) {
 	return t.String(), t.args
 }
 
+// ToSQL returns the SQL representation of the `ALTER TABLE` statement, its arguments
+// and the errors occurred while building it.
+func (t *TableAlter) ToSQL() (string, []any, error) {
+	return toSQL(t)
+}
+
 // IndexAlter is a query builder for `ALTER INDEX` statement.
 type IndexAlter struct {
 	Builder
@@ -400,6 +494,7 @@ func (i *IndexAlter) Rename(name string) *IndexAlter {
 //	ALTER INDEX name
 //		[alter_specification]
 func (i *IndexAlter) query() (string, []any) {
+	i.reset()
 	i.WriteString("ALTER INDEX ")
 	i.Ident(i.name)
 	i.Pad()
@@ -407,6 +502,12 @@ func (i *IndexAlter) query() (string, []any) {
 	return i.String(), i.args
 }
 
+// ToSQL returns the SQL representation of the `ALTER INDEX` statement, its arguments
+// and the errors occurred while building it.
+func (i *IndexAlter) ToSQL() (string, []any, error) {
+	return toSQL(i)
+}
+
 // ForeignKeyBuilder is the builder for the foreign-key constraint clause.
 type ForeignKeyBuilder struct {
 	Builder
@@ -462,6 +563,7 @@ func (fk *ForeignKeyBuilder) OnUpdate(action string) *ForeignKeyBuilder {
 
 // Query returns query representation of a foreign key constraint.
 func (fk *ForeignKeyBuilder) query() (string, []any) {
+	fk.reset()
 	if fk.symbol != "" {
 		fk.Ident(fk.symbol).Pad()
 	}
@@ -476,6 +578,12 @@ func (fk *ForeignKeyBuilder) query() (string, []any) {
 	return fk.String(), fk.args
 }
 
+// ToSQL returns the SQL representation of the foreign-key constraint, its arguments
+// and the errors occurred while building it.
+func (fk *ForeignKeyBuilder) ToSQL() (string, []any, error) {
+	return toSQL(fk)
+}
+
 // ReferenceBuilder is a builder for the reference clause in constraints. For example, in foreign key creation.
 type ReferenceBuilder struct {
 	Builder
@@ -502,6 +610,7 @@ func (r *ReferenceBuilder) Columns(s ...string) *ReferenceBuilder {
 
 // Query returns query representation of a reference clause.
 func (r *ReferenceBuilder) query() (string, []any) {
+	r.reset()
 	r.WriteString("REFERENCES ")
 	r.Ident(r.table)
 	r.Wrap(func(b *Builder) {
@@ -510,6 +619,12 @@ func (r *ReferenceBuilder) query() (string, []any) {
 	return r.String(), r.args
 }
 
+// ToSQL returns the SQL representation of the reference clause, its arguments
+// and the errors occurred while building it.
+func (r *ReferenceBuilder) ToSQL() (string, []any, error) {
+	return toSQL(r)
+}
+
 // IndexBuilder is a builder for `CREATE INDEX` statement.
 type IndexBuilder struct {
 	Builder
@@ -576,6 +691,7 @@ func (i *IndexBuilder) Columns(columns ...string) *IndexBuilder {
 
 // Query returns query representation of a reference clause.
 func (i *IndexBuilder) query() (string, []any) {
+	i.reset()
 	i.WriteString("CREATE ")
 	if i.unique {
 		i.WriteString("UNIQUE ")
@@ -595,7 +711,7 @@ func (i *IndexBuilder) query() (string, []any) {
 		i.Wrap(func(b *Builder) {
 			b.IdentComma(i.columns...)
 		})
-	case MySQL:
+	case MySQL, MySQL8:
 		i.Wrap(func(b *Builder) {
 			b.IdentComma(i.columns...)
 		})
@@ -610,6 +726,12 @@ func (i *IndexBuilder) query() (string, []any) {
 	return i.String(), nil
 }
 
+// ToSQL returns the SQL representation of the `CREATE INDEX` statement, its arguments
+// and the errors occurred while building it.
+func (i *IndexBuilder) ToSQL() (string, []any, error) {
+	return toSQL(i)
+}
+
 // DropIndexBuilder is a builder for `DROP INDEX` statement.
 type DropIndexBuilder struct {
 	Builder
@@ -641,6 +763,7 @@ func (d *DropIndexBuilder) Table(table string) *DropIndexBuilder {
 //
 //	DROP INDEX index_name [ON table_name]
 func (d *DropIndexBuilder) query() (string, []any) {
+	d.reset()
 	d.WriteString("DROP INDEX ")
 	d.Ident(d.name)
 	if d.table != "" {
@@ -650,6 +773,12 @@ func (d *DropIndexBuilder) query() (string, []any) {
 	return d.String(), nil
 }
 
+// ToSQL returns the SQL representation of the `DROP INDEX` statement, its arguments
+// and the errors occurred while building it.
+func (d *DropIndexBuilder) ToSQL() (string, []any, error) {
+	return toSQL(d)
+}
+
 // InsertBuilder is a builder for `INSERT INTO` statement.
 type InsertBuilder struct {
 	Builder
@@ -660,6 +789,9 @@ type InsertBuilder struct {
 	returning []string
 	values    [][]any
 	conflict  *conflict
+	model     *insertModel
+	unscoped  scoping
+	scoped    []scopeValue
 
 	driver *DB
 }
@@ -671,37 +803,39 @@ type InsertBuilder struct {
 //		Values("a8m", 10).
 //		Values("foo", 20)
 //
-// Note: Insert inserts all values in one batch.
+// Note: Insert inserts all values in one batch, use SaveInBatches
+// to split large inserts into multiple statements.
 func Insert(table string) *InsertBuilder { return &InsertBuilder{table: table} }
 
 func (i *InsertBuilder) Save(ctx context.Context) (sql.Result, error) {
+	scoped, err := i.driver.resolveScopes(ctx, i.unscoped)
+	if err != nil {
+		return nil, err
+	}
+	i.scoped = scoped
 
 	for _, iter := range i.driver.beforeInsert {
 		iter(i)
 	}
 
-	statement, args := i.query()
-
-	if i.driver.debug {
-		fmt.Printf("%s: %s %v\n", time.Now().Format(`2006-01-02 15:04:05`), statement, args)
+	statement, args, err := i.ToSQL()
+	if err != nil {
+		return nil, err
 	}
 
-	var (
-		err error
-		res sql.Result
-	)
-
-	switch {
-	case i.driver.tx != nil:
-		res, err = i.driver.tx.ExecContext(ctx, statement, args...)
-	default:
-		res, err = i.driver.driver.ExecContext(ctx, statement, args...)
+	res, err := i.driver.execContext(ctx, statement, args)
+	if err == nil {
+		i.driver.invalidate(ctx, i.table)
 	}
 
 	for _, iter := range i.driver.afterInsert {
 		iter(i, res)
 	}
 
+	if err == nil && i.model != nil {
+		i.model.setLastInsertID(res)
+	}
+
 	return res, err
 }
 
@@ -727,6 +861,58 @@ func (i *InsertBuilder) Set(column string, v any) *InsertBuilder {
 	return i
 }
 
+// Model sets the table (if not set) and the columns of the inserted row from
+// the fields of the given struct pointer. AutoIncrement fields with zero values
+// are skipped, and set from the id generated by the database after Save, if
+// supported by the driver (MySQL and SQLite). Other zero values are inserted,
+// except for the fields with the omitempty option, and the nil pointers of the
+// columns with a default value, which are filled by the database.
+//
+//	type User struct {
+//		ID      int64      `leopard:"column:id;primaryKey;autoIncrement"`
+//		Name    string     `json:"name"`
+//		Active  bool       `leopard:"column:active;default:1"`
+//		Created *time.Time `leopard:"column:created_at;default:CURRENT_TIMESTAMP"`
+//		Note    string     `leopard:"column:note;omitempty"`
+//	}
+//
+//	// INSERT INTO `user` (`name`, `active`) VALUES (?, ?)
+//	db.Insert().Model(&User{Name: "a8m"}).Save(ctx)
+//
+// The columns of the TableSchema returned by the Schema method of the model,
+// generated by the leopards command, are used as well.
+func (i *InsertBuilder) Model(v any) *InsertBuilder {
+	rv, err := modelValue(v)
+	if err != nil {
+		i.AddError(err)
+		return i
+	}
+	if i.table == `` {
+		i.table = modelTable(rv)
+	}
+	schema := modelTableSchema(v)
+	i.model = &insertModel{fields: modelFields(rv.Type())}
+	for _, f := range i.model.fields {
+		fv := rv.FieldByIndex(f.index)
+		auto, def := f.has(`autoIncrement`), f.has(`default`)
+		if c := schema.Column(f.column); c != nil {
+			auto, def = auto || c.AutoIncrement, def || c.Default != ``
+		}
+		switch {
+		case auto && fv.IsZero():
+			auto := f
+			i.model.auto, i.model.value = &auto, fv
+			continue
+		case f.has(`omitempty`) && fv.IsZero():
+			continue
+		case def && fv.Kind() == reflect.Pointer && fv.IsNil():
+			continue
+		}
+		i.Set(f.column, fv.Interface())
+	}
+	return i
+}
+
 // StringOmitErr turn value into string
 func StringOmitErr(value any) string {
 	v := reflect.ValueOf(value)
@@ -909,7 +1095,7 @@ func ResolveWithIgnore() ConflictOption {
 //		)
 //
 //	// Output:
-//	// MySQL: INSERT INTO `users` (`id`, `name`) VALUES(1, 'Mashraki) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`),
+//	// MySQL: INSERT INTO `users` (`id`, `name`) VALUES(1, 'Mashraki) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`)
 //	// PostgreSQL: INSERT INTO "users" ("id") VALUES(1) ON CONFLICT ("id") DO UPDATE SET "id" = "excluded"."id, "name" = "excluded"."name"
 func ResolveWithNewValues() ConflictOption {
 	return func(c *conflict) {
@@ -1005,10 +1191,14 @@ func (u *UpdateSet) SetIgnore(name string) *UpdateSet {
 }
 
 // SetExcluded sets the column name to its EXCLUDED/VALUES value.
-// For example, "c" = "excluded"."c", or `c` = VALUES(`c`).
+// For example, "c" = "excluded"."c", `c` = VALUES(`c`), or `c` = `new`.`c`
+// for dialects with the RowAlias feature (the MySQL8 dialect).
 func (u *UpdateSet) SetExcluded(name string) *UpdateSet {
-	switch u.UpdateBuilder.Dialect() {
-	case MySQL:
+	switch f := u.UpdateBuilder.features(); {
+	case f.Upsert == UpsertOnDuplicateKey && f.RowAlias:
+		t := Dialect(u.UpdateBuilder.dialect).Table(rowAlias)
+		u.UpdateBuilder.Set(name, Expr(t.C(name)))
+	case f.Upsert == UpsertOnDuplicateKey:
 		u.UpdateBuilder.Set(name, ExprFunc(func(b *Builder) {
 			b.WriteString("VALUES(").Ident(name).WriteByte(')')
 		}))
@@ -1028,21 +1218,35 @@ func (i *InsertBuilder) query() (string, []any) {
 // QueryErr returns query representation of an `INSERT INTO`
 // statement and any error occurred in building the statement.
 func (i *InsertBuilder) QueryErr() (string, []any, error) {
+	if err := checkDialect(i.dialect); err != nil {
+		return "", nil, err
+	}
 	b := i.Builder.clone()
+	columns, values := i.scopedValues()
+	if i.conflict != nil && i.features().Upsert == UpsertMerge {
+		i.writeMerge(&b, columns, values)
+		return b.String(), b.args, b.Err()
+	}
 	b.WriteString("INSERT INTO ")
 	b.writeSchema(i.schema)
-	b.Ident(i.table).Pad()
-	if i.defaults && len(i.columns) == 0 {
+	b.Ident(i.table)
+	if i.defaults && len(columns) == 0 {
+		joinOutput(i.returning, &b)
+		b.Pad()
 		i.writeDefault(&b)
 	} else {
-		b.WriteByte('(').IdentComma(i.columns...).WriteByte(')')
+		b.Pad().WriteByte('(').IdentComma(columns...).WriteByte(')')
+		joinOutput(i.returning, &b)
 		b.WriteString(" VALUES ")
-		for j, v := range i.values {
+		for j, v := range values {
 			if j > 0 {
 				b.Comma()
 			}
 			b.WriteByte('(').Args(v...).WriteByte(')')
 		}
+		if f := i.features(); i.conflict != nil && f.Upsert == UpsertOnDuplicateKey && f.RowAlias {
+			b.WriteString(" AS ").Ident(rowAlias)
+		}
 	}
 	if i.conflict != nil {
 		i.writeConflict(&b)
@@ -1051,25 +1255,71 @@ func (i *InsertBuilder) QueryErr() (string, []any, error) {
 	return b.String(), b.args, b.Err()
 }
 
-func (i *InsertBuilder) writeDefault(b *Builder) {
-	switch i.Dialect() {
-	case MySQL:
-		b.WriteString("VALUES ()")
-	case SQLite, Postgres:
-		b.WriteString("DEFAULT VALUES")
+// ToSQL returns the SQL representation of the `INSERT` statement, its arguments
+// and the errors occurred while building it.
+func (i *InsertBuilder) ToSQL() (string, []any, error) {
+	if err := i.Err(); err != nil {
+		return "", nil, err
+	}
+	return i.QueryErr()
+}
+
+// scopedValues returns the columns and values of the statement with the
+// resolved global scopes applied. Scoped columns override the given values.
+func (i *InsertBuilder) scopedValues() ([]string, [][]any) {
+	if len(i.scoped) == 0 {
+		return i.columns, i.values
+	}
+	columns := append([]string{}, i.columns...)
+	values := make([][]any, len(i.values))
+	for j := range i.values {
+		values[j] = append([]any{}, i.values[j]...)
+	}
+	if len(values) == 0 {
+		values = append(values, []any{})
+	}
+	for _, sv := range i.scoped {
+		idx := -1
+		for j, c := range columns {
+			if c == sv.column {
+				idx = j
+				break
+			}
+		}
+		if idx == -1 {
+			columns = append(columns, sv.column)
+		}
+		for j := range values {
+			switch {
+			case idx == -1:
+				values[j] = append(values[j], sv.value)
+			case idx < len(values[j]):
+				values[j][idx] = sv.value
+			}
+		}
 	}
+	return columns, values
+}
+
+func (i *InsertBuilder) writeDefault(b *Builder) {
+	b.WriteString(i.features().DefaultValues)
 }
 
+// rowAlias is the alias of the inserted rows in the `ON DUPLICATE KEY UPDATE`
+// clause of dialects with the RowAlias feature, replacing the VALUES() function
+// deprecated by MySQL 8.0.20. MariaDB and MySQL before 8.0.19 reject it.
+const rowAlias = "new"
+
 func (i *InsertBuilder) writeConflict(b *Builder) {
-	switch i.Dialect() {
-	case MySQL:
+	switch i.features().Upsert {
+	case UpsertOnDuplicateKey:
 		b.WriteString(" ON DUPLICATE KEY UPDATE ")
 		// Fallback to ResolveWithIgnore() as MySQL
 		// does not support the "DO NOTHING" clause.
 		if i.conflict.action.nothing {
 			i.OnConflict(ResolveWithIgnore())
 		}
-	case SQLite, Postgres:
+	case UpsertOnConflict:
 		b.WriteString(" ON CONFLICT")
 		switch t := i.conflict.target; {
 		case t.constraint != "" && len(t.columns) != 0:
@@ -1087,6 +1337,9 @@ func (i *InsertBuilder) writeConflict(b *Builder) {
 			return
 		}
 		b.WriteString(" DO UPDATE SET ")
+	default:
+		b.AddError(fmt.Errorf("ON CONFLICT is not supported by dialect %q", i.dialect))
+		return
 	}
 	if len(i.conflict.action.update) == 0 {
 		b.AddError(errors.New("missing action for 'DO UPDATE SET' clause"))
@@ -1103,6 +1356,72 @@ func (i *InsertBuilder) writeConflict(b *Builder) {
 	}
 }
 
+// writeMerge writes the upsert as a `MERGE` statement, for
+// dialects without the `ON CONFLICT` clause (e.g. SQL Server).
+// The new values are available in the `excluded` source table.
+func (i *InsertBuilder) writeMerge(b *Builder, columns []string, values [][]any) {
+	switch t := i.conflict.target; {
+	case t.constraint != "":
+		b.AddError(fmt.Errorf("MERGE does not support conflict constraints: %q", t.constraint))
+	case t.where != nil:
+		b.AddError(errors.New("MERGE does not support conflict predicates"))
+	case len(t.columns) == 0:
+		b.AddError(errors.New("missing conflict columns for the MERGE statement"))
+	}
+	excluded := b.Quote("excluded")
+	b.WriteString("MERGE INTO ")
+	b.writeSchema(i.schema)
+	b.Ident(i.table).WriteString(" WITH (HOLDLOCK) USING (VALUES ")
+	for j, v := range values {
+		if j > 0 {
+			b.Comma()
+		}
+		b.WriteByte('(').Args(v...).WriteByte(')')
+	}
+	b.WriteString(") AS " + excluded + " (").IdentComma(columns...).WriteString(") ON ")
+	for j, c := range i.conflict.target.columns {
+		if j > 0 {
+			b.WriteString(" AND ")
+		}
+		b.WriteString(b.Quote(i.table) + "." + b.Quote(c) + " = " + excluded + "." + b.Quote(c))
+	}
+	if !i.conflict.action.nothing {
+		b.WriteString(" WHEN MATCHED")
+		if p := i.conflict.action.where; p != nil {
+			p.qualifier = i.table
+			b.WriteString(" AND ").Join(p)
+		}
+		b.WriteString(" THEN UPDATE SET ")
+		if len(i.conflict.action.update) == 0 {
+			b.AddError(errors.New("missing action for 'WHEN MATCHED THEN UPDATE SET' clause"))
+		}
+		// The matched row already holds the conflict columns, and SQL Server
+		// rejects updating them when they are identity columns. They are kept
+		// if there are no other columns, for ResolveWithIgnore.
+		update := exclude(columns, i.conflict.target.columns)
+		if len(update) == 0 {
+			update = columns
+		}
+		u := &UpdateSet{UpdateBuilder: Dialect(i.dialect).Update(i.driver, i.table), columns: update}
+		u.Builder = *b
+		for _, f := range i.conflict.action.update {
+			f(u)
+		}
+		u.writeSetter(b)
+	}
+	b.WriteString(" WHEN NOT MATCHED THEN INSERT (").IdentComma(columns...).WriteString(") VALUES (")
+	for j, c := range columns {
+		if j > 0 {
+			b.Comma()
+		}
+		b.WriteString(excluded + "." + b.Quote(c))
+	}
+	b.WriteByte(')')
+	joinOutput(i.returning, b)
+	// MERGE statements must be terminated by a semicolon.
+	b.WriteByte(';')
+}
+
 // UpdateBuilder is a builder for `UPDATE` statement.
 type UpdateBuilder struct {
 	Builder
@@ -1116,6 +1435,9 @@ type UpdateBuilder struct {
 	order     []any
 	limit     *int
 	prefix    Queries
+	unscoped  scoping
+	scoped    []scopeValue
+	version   *versionLock
 
 	driver *DB
 }
@@ -1161,33 +1483,94 @@ func (u *UpdateBuilder) SetMap(kv map[string]any) *UpdateBuilder {
 	return u
 }
 
+// Model sets the columns of the update from the exported fields of the given
+// struct (or pointer to struct). Fields tagged with the `primaryKey` option, or
+// holding the primary key of the TableSchema returned by the Schema method of
+// the model, are used in the WHERE clause and are not updated. If the table was
+// not set, it defaults to the model's TableName() method or its snake-cased
+// type name.
+//
+// A field tagged with the `version` option enables optimistic locking: the
+// update adds `WHERE version = ?` and `SET version = version + 1`, Save
+// returns a *StaleObjectError (matching ErrStaleObject) if no row was
+// updated, and the field of a pointer model is incremented on success.
+//
+//	type User struct {
+//		Id      int    `leopard:"column:id;primaryKey"`
+//		Name    string `json:"name"`
+//		Version int    `leopard:"column:version;version"`
+//	}
+//
+//	// UPDATE `users` SET `name` = ?, `version` = COALESCE(`users`.`version`, 0) + ?
+//	// WHERE `id` = ? AND `version` = ?
+//	db.Update().Table(`users`).Model(&user).Save(ctx)
+func (u *UpdateBuilder) Model(v any) *UpdateBuilder {
+	rv, err := modelValue(v)
+	if err != nil {
+		u.AddError(err)
+		return u
+	}
+	if u.table == `` {
+		u.table = modelTable(rv)
+	}
+	var keys []*Predicate
+	schema := modelTableSchema(v)
+	for _, f := range modelFields(rv.Type()) {
+		fv := rv.FieldByIndex(f.index)
+		switch {
+		case f.has(`primaryKey`), schema != nil && contains(schema.PrimaryKey, f.column):
+			keys = append(keys, EQ(f.column, fv.Interface()))
+		case f.has(`version`):
+			keys = append(keys, EQ(f.column, fv.Interface()))
+			u.Add(f.column, 1)
+			u.version = &versionLock{column: f.column, value: fv}
+		default:
+			u.Set(f.column, fv.Interface())
+		}
+	}
+	if len(keys) == 0 {
+		u.AddError(fmt.Errorf("Model: missing primaryKey field in %s", rv.Type()))
+		return u
+	}
+	return u.Where(And(keys...))
+}
+
 func (u *UpdateBuilder) Save(ctx context.Context) (sql.Result, error) {
+	scoped, err := u.driver.resolveScopes(ctx, u.unscoped)
+	if err != nil {
+		return nil, err
+	}
+	u.scoped = scoped
+
 	for _, iter := range u.driver.beforeUpdate {
 		iter(u)
 	}
 
-	statement, args := u.query()
-
-	if u.driver.debug {
-		fmt.Printf("%s: %s %v\n", time.Now().Format(`2006-01-02 15:04:05`), statement, args)
+	statement, args, err := u.ToSQL()
+	if err != nil {
+		return nil, err
 	}
 
-	var (
-		err error
-		res sql.Result
-	)
-
-	switch {
-	case u.driver.tx != nil:
-		res, err = u.driver.tx.ExecContext(ctx, statement, args...)
-	default:
-		res, err = u.driver.driver.ExecContext(ctx, statement, args...)
+	res, err := u.driver.execContext(ctx, statement, args)
+	if err == nil {
+		u.driver.invalidate(ctx, u.table)
 	}
 
 	for _, iter := range u.driver.afterUpdate {
 		iter(u, res)
 	}
 
+	if err == nil && u.version != nil {
+		n, rerr := res.RowsAffected()
+		switch {
+		case rerr != nil:
+			return res, rerr
+		case n == 0:
+			return res, &StaleObjectError{Table: u.table, Column: u.version.column, Version: u.version.value.Interface()}
+		}
+		u.version.bump()
+	}
+
 	return res, err
 }
 
@@ -1239,8 +1622,8 @@ func (u *UpdateBuilder) Empty() bool {
 // OrderBy appends the `ORDER BY` clause to the `UPDATE` statement.
 // Supported by SQLite and MySQL.
 func (u *UpdateBuilder) OrderBy(columns ...string) *UpdateBuilder {
-	if u.postgres() {
-		u.AddError(errors.New("ORDER BY is not supported by PostgreSQL"))
+	if u.features().MutationLimit != LimitClause {
+		u.AddError(fmt.Errorf("ORDER BY is not supported by dialect %q", u.dialect))
 		return u
 	}
 	for i := range columns {
@@ -1250,10 +1633,10 @@ func (u *UpdateBuilder) OrderBy(columns ...string) *UpdateBuilder {
 }
 
 // Limit appends the `LIMIT` clause to the `UPDATE` statement.
-// Supported by SQLite and MySQL.
+// Supported by SQLite, MySQL and SQL Server (`UPDATE TOP (n)`).
 func (u *UpdateBuilder) Limit(limit int) *UpdateBuilder {
-	if u.postgres() {
-		u.AddError(errors.New("LIMIT is not supported by PostgreSQL"))
+	if u.features().MutationLimit == LimitNone {
+		u.AddError(fmt.Errorf("LIMIT is not supported by dialect %q", u.dialect))
 		return u
 	}
 	u.limit = &limit
@@ -1281,22 +1664,28 @@ func (u *UpdateBuilder) query() (string, []any) {
 		b.Pad()
 	}
 	b.WriteString("UPDATE ")
+	joinTop(u.limit, &b)
 	b.writeSchema(u.schema)
 	b.Ident(u.table).WriteString(" SET ")
 	u.writeSetter(&b)
-	if u.where != nil {
+	joinOutput(u.returning, &b)
+	if where := scopedWhere(u.where, u.scoped, nil); where != nil {
 		b.WriteString(" WHERE ")
-		b.Join(u.where)
+		b.Join(where)
 	}
 	joinReturning(u.returning, &b)
 	joinOrder(u.order, &b)
-	if u.limit != nil {
-		b.WriteString(" LIMIT ")
-		b.WriteString(strconv.Itoa(*u.limit))
-	}
+	joinMutationLimit(u.limit, &b)
+	u.AddError(b.Err())
 	return b.String(), b.args
 }
 
+// ToSQL returns the SQL representation of the `UPDATE` statement, its arguments
+// and the errors occurred while building it.
+func (u *UpdateBuilder) ToSQL() (string, []any, error) {
+	return toSQL(u)
+}
+
 // writeSetter writes the "SET" clause for the UPDATE statement.
 func (u *UpdateBuilder) writeSetter(b *Builder) {
 	for i, c := range u.nulls {
@@ -1325,9 +1714,12 @@ func (u *UpdateBuilder) writeSetter(b *Builder) {
 // DeleteBuilder is a builder for `DELETE` statement.
 type DeleteBuilder struct {
 	Builder
-	table  string
-	schema string
-	where  *Predicate
+	table    string
+	schema   string
+	where    *Predicate
+	limit    *int
+	unscoped scoping
+	scoped   []scopeValue
 
 	driver *DB
 }
@@ -1348,26 +1740,24 @@ type DeleteBuilder struct {
 func Delete(table string) *DeleteBuilder { return &DeleteBuilder{table: table} }
 
 func (d *DeleteBuilder) Exec(ctx context.Context) (sql.Result, error) {
+	scoped, err := d.driver.resolveScopes(ctx, d.unscoped)
+	if err != nil {
+		return nil, err
+	}
+	d.scoped = scoped
+
 	for _, iter := range d.driver.beforeDelete {
 		iter(d)
 	}
 
-	statement, args := d.query()
-
-	if d.driver.debug {
-		fmt.Printf("%s: %s %v\n", time.Now().Format(`2006-01-02 15:04:05`), statement, args)
+	statement, args, err := d.ToSQL()
+	if err != nil {
+		return nil, err
 	}
 
-	var (
-		err error
-		res sql.Result
-	)
-
-	switch {
-	case d.driver.tx != nil:
-		res, err = d.driver.tx.ExecContext(ctx, statement, args...)
-	default:
-		res, err = d.driver.driver.ExecContext(ctx, statement, args...)
+	res, err := d.driver.execContext(ctx, statement, args)
+	if err == nil {
+		d.driver.invalidate(ctx, d.table)
 	}
 
 	for _, iter := range d.driver.afterDelete {
@@ -1428,6 +1818,17 @@ func (d *DeleteBuilder) WhereOrMap(cond map[string]any) *DeleteBuilder {
 	return d
 }
 
+// Limit limits the number of rows deleted by the `DELETE` statement.
+// Supported by SQLite, MySQL and SQL Server (`DELETE TOP (n)`).
+func (d *DeleteBuilder) Limit(limit int) *DeleteBuilder {
+	if d.features().MutationLimit == LimitNone {
+		d.AddError(fmt.Errorf("LIMIT is not supported by dialect %q", d.dialect))
+		return d
+	}
+	d.limit = &limit
+	return d
+}
+
 // FromSelect makes it possible to delete a sub query.
 func (d *DeleteBuilder) FromSelect(s *Selector) *DeleteBuilder {
 	d.Where(s.where)
@@ -1439,16 +1840,26 @@ func (d *DeleteBuilder) FromSelect(s *Selector) *DeleteBuilder {
 
 // Query returns query representation of a `DELETE` statement.
 func (d *DeleteBuilder) query() (string, []any) {
-	d.WriteString("DELETE FROM ")
+	d.reset()
+	d.WriteString("DELETE ")
+	joinTop(d.limit, &d.Builder)
+	d.WriteString("FROM ")
 	d.writeSchema(d.schema)
 	d.Ident(d.table)
-	if d.where != nil {
+	if where := scopedWhere(d.where, d.scoped, nil); where != nil {
 		d.WriteString(" WHERE ")
-		d.Join(d.where)
+		d.Join(where)
 	}
+	joinMutationLimit(d.limit, &d.Builder)
 	return d.String(), d.args
 }
 
+// ToSQL returns the SQL representation of the `DELETE` statement, its arguments
+// and the errors occurred while building it.
+func (d *DeleteBuilder) ToSQL() (string, []any, error) {
+	return toSQL(d)
+}
+
 // Predicate is a where predicate.
 type Predicate struct {
 	Builder
@@ -1637,7 +2048,7 @@ func LT(col string, value any) *Predicate {
 func (p *Predicate) LT(col string, arg any) *Predicate {
 	return p.Append(func(b *Builder) {
 		b.Ident(col)
-		p.WriteOp(OpLT)
+		b.WriteOp(OpLT)
 		p.arg(b, arg)
 	})
 }
@@ -1661,7 +2072,7 @@ func LTE(col string, value any) *Predicate {
 func (p *Predicate) LTE(col string, arg any) *Predicate {
 	return p.Append(func(b *Builder) {
 		b.Ident(col)
-		p.WriteOp(OpLTE)
+		b.WriteOp(OpLTE)
 		p.arg(b, arg)
 	})
 }
@@ -1670,9 +2081,9 @@ func (p *Predicate) LTE(col string, arg any) *Predicate {
 func (p *Predicate) BetweenAnd(col string, v1, v2 any) *Predicate {
 	return p.Append(func(b *Builder) {
 		b.Ident(col)
-		p.WriteOp(OpBetween)
+		b.WriteOp(OpBetween)
 		p.arg(b, v1)
-		p.WriteOp(OpBetweenAnd)
+		b.WriteOp(OpBetweenAnd)
 		p.arg(b, v2)
 	})
 }
@@ -1696,7 +2107,7 @@ func GT(col string, value any) *Predicate {
 func (p *Predicate) GT(col string, arg any) *Predicate {
 	return p.Append(func(b *Builder) {
 		b.Ident(col)
-		p.WriteOp(OpGT)
+		b.WriteOp(OpGT)
 		p.arg(b, arg)
 	})
 }
@@ -1725,7 +2136,7 @@ func Between(col string, v1, v2 any) *Predicate {
 func (p *Predicate) GTE(col string, arg any) *Predicate {
 	return p.Append(func(b *Builder) {
 		b.Ident(col)
-		p.WriteOp(OpGTE)
+		b.WriteOp(OpGTE)
 		p.arg(b, arg)
 	})
 }
@@ -1913,8 +2324,8 @@ func (p *Predicate) escapedLike(col, left, right, word string) *Predicate {
 		w, escaped := escape(word)
 		b.Ident(col).WriteOp(OpLike)
 		b.Arg(left + w + right)
-		if p.dialect == SQLite && escaped {
-			p.WriteString(" ESCAPE ").Arg("\\")
+		if b.dialect == SQLite && escaped {
+			b.WriteString(" ESCAPE ").Arg("\\")
 		}
 	})
 }
@@ -1937,8 +2348,8 @@ func ColumnsHasPrefix(col, prefixC string) *Predicate {
 // ColumnsHasPrefix appends a new predicate that checks if the given column begins with the other column (prefix).
 func (p *Predicate) ColumnsHasPrefix(col, prefixC string) *Predicate {
 	return p.Append(func(b *Builder) {
-		switch p.dialect {
-		case MySQL:
+		switch b.dialect {
+		case MySQL, MySQL8:
 			b.Ident(col)
 			b.WriteOp(OpLike)
 			b.S("CONCAT(REPLACE(REPLACE(").Ident(prefixC).S(", '_', '\\_'), '%', '\\%'), '%')")
@@ -1946,11 +2357,11 @@ func (p *Predicate) ColumnsHasPrefix(col, prefixC string) *Predicate {
 			b.Ident(col)
 			b.WriteOp(OpLike)
 			b.S("(REPLACE(REPLACE(").Ident(prefixC).S(", '_', '\\_'), '%', '\\%') || '%')")
-			if p.dialect == SQLite {
-				p.WriteString(" ESCAPE ").Arg("\\")
+			if b.dialect == SQLite {
+				b.WriteString(" ESCAPE ").Arg("\\")
 			}
 		default:
-			b.AddError(fmt.Errorf("ColumnsHasPrefix: unsupported dialect: %q", p.dialect))
+			b.AddError(fmt.Errorf("ColumnsHasPrefix: unsupported dialect: %q", b.dialect))
 		}
 	})
 }
@@ -1972,7 +2383,7 @@ func (p *Predicate) EqualFold(col, sub string) *Predicate {
 		f := &Func{}
 		f.SetDialect(b.dialect)
 		switch b.dialect {
-		case MySQL:
+		case MySQL, MySQL8:
 			// We assume the CHARACTER SET is configured to utf8mb4,
 			// because this how it is defined in dialect/sql/schema.
 			b.Ident(col).WriteString(" COLLATE utf8mb4_general_ci = ")
@@ -2006,7 +2417,7 @@ func (p *Predicate) ContainsFold(col, substr string) *Predicate {
 	return p.Append(func(b *Builder) {
 		w, escaped := escape(substr)
 		switch b.dialect {
-		case MySQL:
+		case MySQL, MySQL8:
 			// We assume the CHARACTER SET is configured to utf8mb4,
 			// because this how it is defined in dialect/sql/schema.
 			b.Ident(col).WriteString(" COLLATE utf8mb4_general_ci LIKE ")
@@ -2021,7 +2432,7 @@ func (p *Predicate) ContainsFold(col, substr string) *Predicate {
 			b.WriteString(f.String()).WriteString(" LIKE ")
 			b.Arg("%" + strings.ToLower(w) + "%")
 			if escaped {
-				p.WriteString(" ESCAPE ").Arg("\\")
+				b.WriteString(" ESCAPE ").Arg("\\")
 			}
 		}
 	})
@@ -2071,8 +2482,7 @@ func (p *Predicate) Append(f func(*Builder)) *Predicate {
 // query returns query representation of a predicate.
 func (p *Predicate) query() (string, []any) {
 	if p.Len() > 0 || len(p.args) > 0 {
-		p.Reset()
-		p.args = nil
+		p.reset()
 	}
 	for _, f := range p.fns {
 		f(&p.Builder)
@@ -2080,6 +2490,12 @@ func (p *Predicate) query() (string, []any) {
 	return p.String(), p.args
 }
 
+// ToSQL returns the SQL representation of the predicate, its arguments
+// and the errors occurred while building it.
+func (p *Predicate) ToSQL() (string, []any, error) {
+	return toSQL(p)
+}
+
 // arg calls Builder.Arg, but wraps `a` with parens in case of a Selector.
 func (*Predicate) arg(b *Builder, a any) {
 	switch a.(type) {
@@ -2388,6 +2804,10 @@ type Selector struct {
 	setOps    []setOp
 	prefix    Queries
 	lock      *LockOptions
+	unscoped  scoping
+	scoped    []scopeValue
+	cursor    *cursor
+	ttl       *time.Duration
 
 	// driver
 	driver *DB
@@ -2395,30 +2815,34 @@ type Selector struct {
 
 func (s *Selector) Scan(ctx context.Context, dest any) error {
 	var err error
-	var rows *sql.Rows
 
-	for _, iter := range s.driver.beforeQuery {
-		iter(s)
+	if s.scoped, err = s.driver.resolveScopes(ctx, s.unscoped); err != nil {
+		return err
 	}
 
-	statement, args := s.query()
-
-	if s.driver.debug {
-		fmt.Printf("%s: %s %v\n", time.Now().Format(`2006-01-02 15:04:05`), statement, args)
+	for _, iter := range s.driver.beforeQuery {
+		iter(s)
 	}
 
-	if s.driver.tx != nil {
-		rows, err = s.driver.tx.QueryContext(ctx, statement, args...)
-	} else {
-		rows, err = s.driver.driver.QueryContext(ctx, statement, args...)
-	}
+	statement, args, err := s.ToSQL()
 	if err != nil {
 		return err
 	}
 
-	err = s.driver.Scan(rows, dest)
-	if err != nil {
-		_ = rows.Close()
+	if s.cacheable() {
+		if err = s.scanCache(ctx, statement, args, dest); err != nil {
+			return err
+		}
+	} else {
+		var rows *sql.Rows
+		if rows, err = s.driver.queryContext(ctx, statement, args); err != nil {
+			return err
+		}
+
+		err = s.driver.Scan(rows, dest)
+		if err != nil {
+			_ = rows.Close()
+		}
 	}
 
 	for _, iter := range s.driver.afterQuery {
@@ -2533,18 +2957,15 @@ func (s *Selector) AppendSelectExprAs(expr Querier, as string) *Selector {
 // FindSelection returns all occurrences in the selection that match the given column name.
 // For example, for column "a" the following match: a, "a", "t"."a", "t"."b" AS "a".
 func (s *Selector) FindSelection(name string) (matches []string) {
+	open, close := s.dialector().IdentQuote()
 	matchC := func(qualified string) bool {
-		switch ident, pg := s.isIdent(qualified), s.postgres(); {
+		switch ident := s.isIdent(qualified); {
 		case !ident:
 			if i := strings.IndexRune(qualified, '.'); i > 0 {
 				return qualified[i+1:] == name
 			}
-		case ident && pg:
-			if i := strings.Index(qualified, `"."`); i > 0 {
-				return s.unquote(qualified[i+2:]) == name
-			}
 		case ident:
-			if i := strings.Index(qualified, "`.`"); i > 0 {
+			if i := strings.Index(qualified, string([]byte{close, '.', open})); i > 0 {
 				return s.unquote(qualified[i+2:]) == name
 			}
 		}
@@ -3112,8 +3533,8 @@ func WithLockClause(clause string) LockOption {
 // For sets the lock configuration for suffixing the `SELECT`
 // statement with the `FOR [SHARE | UPDATE] ...` clause.
 func (s *Selector) For(l LockStrength, opts ...LockOption) *Selector {
-	if s.Dialect() == SQLite {
-		s.AddError(errors.New("sql: SELECT .. FOR UPDATE/SHARE not supported in SQLite"))
+	if !s.features().Lock {
+		s.AddError(fmt.Errorf("sql: SELECT .. FOR UPDATE/SHARE not supported by dialect %q", s.dialect))
 	}
 	s.lock = &LockOptions{Strength: l}
 	for _, opt := range opts {
@@ -3160,6 +3581,13 @@ func (s *Selector) Clone() *Selector {
 		group:     append([]string{}, s.group...),
 		order:     append([]any{}, s.order...),
 		selection: append([]selection{}, s.selection...),
+		setOps:    append([]setOp{}, s.setOps...),
+		prefix:    append(Queries{}, s.prefix...),
+		lock:      s.lock,
+		unscoped:  s.unscoped,
+		cursor:    s.cursor,
+		ttl:       s.ttl,
+		driver:    s.driver,
 	}
 }
 
@@ -3302,9 +3730,9 @@ func (s *Selector) query() (string, []any) {
 			b.Join(join.on)
 		}
 	}
-	if s.where != nil {
+	if where := scopedWhere(s.where, s.scoped, s.scopeColumn); where != nil {
 		b.WriteString(" WHERE ")
-		b.Join(s.where)
+		b.Join(where)
 	}
 	if len(s.group) > 0 {
 		b.WriteString(" GROUP BY ")
@@ -3318,20 +3746,61 @@ func (s *Selector) query() (string, []any) {
 		s.joinSetOps(&b)
 	}
 	joinOrder(s.order, &b)
-	if s.limit != nil {
-		b.WriteString(" LIMIT ")
-		b.WriteString(strconv.Itoa(*s.limit))
-	}
-	if s.offset != nil {
-		b.WriteString(" OFFSET ")
-		b.WriteString(strconv.Itoa(*s.offset))
-	}
+	s.joinLimit(&b)
 	s.joinLock(&b)
-	s.total = b.total
 	s.AddError(b.Err())
 	return b.String(), b.args
 }
 
+// joinLimit writes the LIMIT and OFFSET clauses of the `SELECT` statement.
+func (s *Selector) joinLimit(b *Builder) {
+	if s.limit == nil && s.offset == nil {
+		return
+	}
+	switch b.features().Limit {
+	case LimitClause:
+		if s.limit != nil {
+			b.WriteString(" LIMIT ")
+			b.WriteString(strconv.Itoa(*s.limit))
+		}
+		if s.offset != nil {
+			b.WriteString(" OFFSET ")
+			b.WriteString(strconv.Itoa(*s.offset))
+		}
+	case LimitFetch:
+		// OFFSET/FETCH requires the ORDER BY clause.
+		if len(s.order) == 0 {
+			b.WriteString(" ORDER BY (SELECT NULL)")
+		}
+		offset := 0
+		if s.offset != nil {
+			offset = *s.offset
+		}
+		b.WriteString(" OFFSET " + strconv.Itoa(offset) + " ROWS")
+		if s.limit != nil {
+			b.WriteString(" FETCH NEXT " + strconv.Itoa(*s.limit) + " ROWS ONLY")
+		}
+	default:
+		b.AddError(fmt.Errorf("LIMIT is not supported by dialect %q", b.dialect))
+	}
+}
+
+// ToSQL returns the SQL representation of the `SELECT` statement, its arguments
+// and the errors occurred while building it.
+func (s *Selector) ToSQL() (string, []any, error) {
+	return toSQL(s)
+}
+
+// scopeColumn qualifies a global scope column with the selected table.
+func (s *Selector) scopeColumn(column string) string {
+	if len(s.from) > 0 {
+		if t, ok := s.from[0].(*SelectTable); ok {
+			return t.C(column)
+		}
+	}
+	return column
+}
+
 func (s *Selector) joinPrefix(b *Builder) {
 	if len(s.prefix) > 0 {
 		b.join(s.prefix, " ")
@@ -3396,8 +3865,40 @@ func joinOrder(order []any, b *Builder) {
 	}
 }
 
+// joinOutput writes the `OUTPUT INSERTED` clause of the
+// dialects returning the affected rows with OUTPUT.
+func joinOutput(columns []string, b *Builder) {
+	if len(columns) == 0 || b.features().Returning != ReturningOutput {
+		return
+	}
+	b.WriteString(" OUTPUT ")
+	for i, c := range columns {
+		if i > 0 {
+			b.Comma()
+		}
+		b.WriteString("INSERTED.").Ident(c)
+	}
+}
+
+// joinTop writes the `TOP (n)` clause of an `UPDATE` or `DELETE`
+// statement, for the dialects limiting them with TOP.
+func joinTop(limit *int, b *Builder) {
+	if limit != nil && b.features().MutationLimit == LimitTop {
+		b.WriteString("TOP (" + strconv.Itoa(*limit) + ") ")
+	}
+}
+
+// joinMutationLimit writes the `LIMIT` clause of an `UPDATE` or `DELETE`
+// statement, for the dialects limiting them with LIMIT.
+func joinMutationLimit(limit *int, b *Builder) {
+	if limit != nil && b.features().MutationLimit == LimitClause {
+		b.WriteString(" LIMIT ")
+		b.WriteString(strconv.Itoa(*limit))
+	}
+}
+
 func joinReturning(columns []string, b *Builder) {
-	if len(columns) == 0 || (!b.postgres() && !b.sqlite()) {
+	if len(columns) == 0 || b.features().Returning != ReturningClause {
 		return
 	}
 	b.WriteString(" RETURNING ")
@@ -3494,6 +3995,7 @@ func (w *WithBuilder) C(column string) string {
 
 // Query returns query representation of a `WITH` clause.
 func (w *WithBuilder) query() (string, []any) {
+	w.reset()
 	w.WriteString("WITH ")
 	if w.recursive {
 		w.WriteString("RECURSIVE ")
@@ -3516,6 +4018,12 @@ func (w *WithBuilder) query() (string, []any) {
 	return w.String(), w.args
 }
 
+// ToSQL returns the SQL representation of the `WITH` statement, its arguments
+// and the errors occurred while building it.
+func (w *WithBuilder) ToSQL() (string, []any, error) {
+	return toSQL(w)
+}
+
 // implement the table view interface.
 func (*WithBuilder) view() {}
 
@@ -3585,6 +4093,7 @@ func (w *WindowBuilder) OrderExpr(exprs ...Querier) *WindowBuilder {
 
 // Query returns query representation of the window function.
 func (w *WindowBuilder) query() (string, []any) {
+	w.reset()
 	w.fn(&w.Builder)
 	w.WriteString(" OVER ")
 	w.Wrap(func(b *Builder) {
@@ -3597,6 +4106,12 @@ func (w *WindowBuilder) query() (string, []any) {
 	return w.Builder.String(), w.args
 }
 
+// ToSQL returns the SQL representation of the window function, its arguments
+// and the errors occurred while building it.
+func (w *WindowBuilder) ToSQL() (string, []any, error) {
+	return toSQL(w)
+}
+
 // Wrapper wraps a given Querier with different format.
 // Used to prefix/suffix other queries.
 type Wrapper struct {
@@ -3704,6 +4219,7 @@ type Builder struct {
 	dialect   string           // configured dialect.
 	args      []any            // query parameters.
 	total     int              // total number of parameters in query tree.
+	offset    int              // number of parameters preceding the query, set by SetTotal.
 	errs      []error          // errors that added during the query construction.
 	qualifier string           // qualifier to prefix identifiers (e.g. table name).
 }
@@ -3711,20 +4227,48 @@ type Builder struct {
 // Quote quotes the given identifier with the characters based
 // on the configured dialect. It defaults to "`".
 func (b *Builder) Quote(ident string) string {
-	quote := "`"
+	open, close := b.dialector().IdentQuote()
 	switch {
-	case b.postgres():
-		// If it was quoted with the wrong
-		// identifier character.
-		if strings.Contains(ident, "`") {
-			return strings.ReplaceAll(ident, "`", `"`)
-		}
-		quote = `"`
+	// If it was quoted with the wrong
+	// identifier character.
+	case open != '`' && strings.Contains(ident, "`"):
+		return b.requote(ident)
 	// An identifier for unknown dialect.
 	case b.dialect == "" && strings.ContainsAny(ident, "`\""):
 		return ident
 	}
-	return quote + ident + quote
+	if open != close {
+		// Bracket quotes (e.g. [ident]) escape the closing
+		// character by doubling it.
+		ident = strings.ReplaceAll(ident, string(close), string([]byte{close, close}))
+	}
+	return string(open) + ident + string(close)
+}
+
+// requote replaces the "`" quotes of the given string
+// with the identifier quotes of the configured dialect.
+func (b *Builder) requote(s string) string {
+	open, close := b.dialector().IdentQuote()
+	if open == '`' {
+		return s
+	}
+	r := make([]byte, 0, len(s)+2)
+	for i, opened := 0, false; i < len(s); i++ {
+		switch {
+		case s[i] == '`' && opened:
+			r = append(r, close)
+		case s[i] == '`':
+			r = append(r, open)
+		case s[i] == close && opened && open != close:
+			r = append(r, close, close)
+		default:
+			r = append(r, s[i])
+		}
+		if s[i] == '`' {
+			opened = !opened
+		}
+	}
+	return string(r)
 }
 
 // Ident appends the given string as an identifier.
@@ -3736,10 +4280,10 @@ func (b *Builder) Ident(s string) *Builder {
 			b.WriteString(b.Quote(b.qualifier)).WriteByte('.')
 		}
 		b.WriteString(b.Quote(s))
-	case (isFunc(s) || isModifier(s) || isAlias(s)) && b.postgres():
+	case isFunc(s) || isModifier(s) || isAlias(s):
 		// Modifiers and aggregation functions that
 		// were called without dialect information.
-		b.WriteString(strings.ReplaceAll(s, "`", `"`))
+		b.WriteString(b.requote(s))
 	default:
 		b.WriteString(s)
 	}
@@ -3804,6 +4348,14 @@ func (b *Builder) Reset() *Builder {
 	return b
 }
 
+// reset clears the accumulated query and arguments of the
+// builder, to allow rendering a statement more than once.
+func (b *Builder) reset() {
+	b.Reset()
+	b.total = b.offset
+	b.args = nil
+}
+
 // AddError appends an error to the builder errors.
 func (b *Builder) AddError(err error) *Builder {
 	// allowed nil error make build process easier
@@ -3814,7 +4366,7 @@ func (b *Builder) AddError(err error) *Builder {
 }
 
 func (b *Builder) writeSchema(schema string) {
-	if schema != "" && b.dialect != SQLite {
+	if schema != "" && b.features().Schema {
 		b.Ident(schema).WriteByte('.')
 	}
 }
@@ -3924,13 +4476,7 @@ func (b *Builder) Arg(a any) *Builder {
 		b.Join(v)
 		return b
 	}
-	// Default placeholder param (MySQL and SQLite).
-	format := "?"
-	if b.postgres() {
-		// Postgres' arguments are referenced using the syntax $n.
-		// $1 refers to the 1st argument, $2 to the 2nd, and so on.
-		format = "$" + strconv.Itoa(b.total+1)
-	}
+	format := b.dialector().Placeholder(b.total + 1)
 	if f, ok := a.(ParamFormatter); ok {
 		format = f.FormatParam(format, &StmtInfo{
 			Dialect: b.dialect,
@@ -4055,6 +4601,7 @@ func (b Builder) Total() int {
 // Used to pass this information between sub queries/expressions.
 func (b *Builder) SetTotal(total int) {
 	b.total = total
+	b.offset = total
 }
 
 // query implements the Querier interface.
@@ -4064,7 +4611,7 @@ func (b Builder) query() (string, []any) {
 
 // clone returns a shallow clone of a builder.
 func (b Builder) clone() Builder {
-	c := Builder{dialect: b.dialect, total: b.total, sb: &strings.Builder{}}
+	c := Builder{dialect: b.dialect, total: b.total, offset: b.offset, sb: &strings.Builder{}}
 	if len(b.args) > 0 {
 		c.args = append(c.args, b.args...)
 	}
@@ -4074,6 +4621,16 @@ func (b Builder) clone() Builder {
 	return c
 }
 
+// dialector returns the Dialector of the builder dialect.
+func (b Builder) dialector() Dialector {
+	return dialectOf(b.Dialect())
+}
+
+// features returns the syntax supported by the builder dialect.
+func (b Builder) features() Features {
+	return b.dialector().Features()
+}
+
 // postgres reports if the builder dialect is PostgreSQL.
 func (b Builder) postgres() bool {
 	return b.Dialect() == Postgres
@@ -4094,32 +4651,31 @@ func (b *Builder) fromIdent(ident string) {
 
 // isIdent reports if the given string is a dialect identifier.
 func (b *Builder) isIdent(s string) bool {
-	switch {
-	case b.postgres():
-		return strings.Contains(s, `"`)
-	default:
-		return strings.Contains(s, "`")
-	}
+	open, _ := b.dialector().IdentQuote()
+	return strings.IndexByte(s, open) != -1
 }
 
 // unquote database identifiers.
 func (b *Builder) unquote(s string) string {
-	switch pg := b.postgres(); {
-	case len(s) < 2:
-	case !pg && s[0] == '`' && s[len(s)-1] == '`', pg && s[0] == '"' && s[len(s)-1] == '"':
+	open, close := b.dialector().IdentQuote()
+	switch {
+	case len(s) < 2 || s[0] != open || s[len(s)-1] != close:
+	case open == '`' || open == '"':
 		if u, err := strconv.Unquote(s); err == nil {
 			return u
 		}
+	default:
+		return strings.ReplaceAll(s[1:len(s)-1], string([]byte{close, close}), string(close))
 	}
 	return s
 }
 
-// isIdent reports if the given string is a qualified identifier.
+// isQualified reports if the given string is a qualified identifier.
 func (b *Builder) isQualified(s string) bool {
-	ident, pg := b.isIdent(s), b.postgres()
+	open, close := b.dialector().IdentQuote()
+	ident := b.isIdent(s)
 	return !ident && len(s) > 2 && strings.ContainsRune(s[1:len(s)-1], '.') || // <qualifier>.<column>
-		ident && pg && strings.Contains(s, `"."`) || // "qualifier"."column"
-		ident && !pg && strings.Contains(s, "`.`") // `qualifier`.`column`
+		ident && strings.Contains(s, string([]byte{close, '.', open})) // "qualifier"."column"
 }
 
 // state wraps the all methods for setting and getting
@@ -4137,6 +4693,7 @@ type DialectBuilder struct {
 }
 
 // Dialect creates a new DialectBuilder with the given dialect name.
+// The statements of an unregistered dialect fail to render.
 func Dialect(name string) *DialectBuilder {
 	return &DialectBuilder{name}
 }
@@ -0,0 +1,187 @@
+package leopards
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Rendering a statement more than once, or its clone, gives the same
+// placeholders and arguments.
+func TestToSQLIdempotent(t *testing.T) {
+	b := Dialect(Postgres)
+	join := func() *Selector {
+		s := b.Select(nil).From(`users`).Where(EQ(`id`, 2))
+		return s.Join(b.Select(nil).From(`pets`).Where(EQ(`age`, 3)).As(`p`)).On(`uid`, `owner_id`)
+	}
+	with := func() *Selector {
+		w := b.With(`w`).As(b.Select(nil).From(`groups`).Where(EQ(`name`, `a`)))
+		return join().Prefix(w)
+	}
+	p := Or(EQ(`a`, 1), In(`b`, 2, 3))
+	p.SetDialect(Postgres)
+	tests := []struct {
+		name      string
+		q         interface{ ToSQL() (string, []any, error) }
+		statement string
+		args      []any
+	}{
+		{
+			name:      `select`,
+			q:         b.Select(nil).From(`users`).Where(And(EQ(`id`, 2), GT(`age`, 3))),
+			statement: `SELECT * FROM "users" WHERE "id" = $1 AND "age" > $2`,
+			args:      []any{2, 3},
+		},
+		{
+			name:      `join`,
+			q:         join(),
+			statement: `SELECT * FROM "users" JOIN (SELECT * FROM "pets" WHERE "age" = $1) AS "p" ON "uid" = "owner_id" WHERE "id" = $2`,
+			args:      []any{3, 2},
+		},
+		{
+			name:      `with`,
+			q:         with(),
+			statement: `WITH "w" AS (SELECT * FROM "groups" WHERE "name" = $1) SELECT * FROM "users" JOIN (SELECT * FROM "pets" WHERE "age" = $2) AS "p" ON "uid" = "owner_id" WHERE "id" = $3`,
+			args:      []any{`a`, 3, 2},
+		},
+		{
+			name:      `clone`,
+			q:         with().Clone(),
+			statement: `WITH "w" AS (SELECT * FROM "groups" WHERE "name" = $1) SELECT * FROM "users" JOIN (SELECT * FROM "pets" WHERE "age" = $2) AS "p" ON "uid" = "owner_id" WHERE "id" = $3`,
+			args:      []any{`a`, 3, 2},
+		},
+		{
+			name:      `predicate`,
+			q:         p,
+			statement: `"a" = $1 OR "b" IN ($2, $3)`,
+			args:      []any{1, 2, 3},
+		},
+		{
+			name:      `update`,
+			q:         b.Update(nil, `users`).Set(`name`, `a`).Where(EQ(`id`, 2)),
+			statement: `UPDATE "users" SET "name" = $1 WHERE "id" = $2`,
+			args:      []any{`a`, 2},
+		},
+		{
+			name:      `insert`,
+			q:         b.Insert(nil, `users`).Columns(`name`).Values(`a`).Values(`b`).Returning(`id`),
+			statement: `INSERT INTO "users" ("name") VALUES ($1), ($2) RETURNING "id"`,
+			args:      []any{`a`, `b`},
+		},
+		{
+			name:      `delete`,
+			q:         b.Delete(nil, `users`).Where(In(`id`, b.Select(nil).Select(`id`).From(`pets`).Where(EQ(`age`, 3)))),
+			statement: `DELETE FROM "users" WHERE "id" IN (SELECT "id" FROM "pets" WHERE "age" = $1)`,
+			args:      []any{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 3; i++ {
+				assertSQL(t, tt.q, tt.statement, tt.args...)
+			}
+		})
+	}
+
+	// A statement rendered alone keeps its placeholders once
+	// rendered as a sub-query of another statement.
+	sub := b.Select(nil).Select(`id`).From(`pets`).Where(EQ(`age`, 3))
+	assertSQL(t, sub, `SELECT "id" FROM "pets" WHERE "age" = $1`, 3)
+	s := b.Select(nil).From(`users`).Where(And(EQ(`name`, `a`), In(`id`, sub)))
+	for i := 0; i < 2; i++ {
+		assertSQL(t, s, `SELECT * FROM "users" WHERE "name" = $1 AND "id" IN (SELECT "id" FROM "pets" WHERE "age" = $2)`, `a`, 3)
+	}
+}
+
+func TestToSQLError(t *testing.T) {
+	s := Dialect(MySQL).Select(nil).From(`users`)
+	s.AddError(errors.New(`bad column`))
+	if _, _, err := s.ToSQL(); err == nil || err.Error() != `bad column` {
+		t.Errorf("ToSQL error = %v, want bad column", err)
+	}
+}
+
+func TestUnknownDialect(t *testing.T) {
+	b := Dialect(`postgress`)
+	for _, q := range []interface{ ToSQL() (string, []any, error) }{
+		b.Select(nil).From(`users`).Where(EQ(`id`, 1)),
+		b.Insert(nil, `users`).Columns(`id`).Values(1),
+		b.Update(nil, `users`).Set(`id`, 1),
+		b.Delete(nil, `users`).Where(EQ(`id`, 1)),
+		b.CreateTableFrom(struct{ ID int }{}),
+	} {
+		if _, _, err := q.ToSQL(); err == nil || !strings.Contains(err.Error(), `unknown dialect "postgress"`) {
+			t.Errorf("ToSQL error = %v, want unknown dialect", err)
+		}
+	}
+	// Builders without a dialect use the MySQL syntax.
+	assertSQL(t, Select(nil).From(`users`).Where(EQ(`id`, 1)), "SELECT * FROM `users` WHERE `id` = ?", 1)
+}
+
+func TestSQLServer(t *testing.T) {
+	b := Dialect(SQLServer)
+	t.Run(`merge`, func(t *testing.T) {
+		i := b.Insert(nil, `users`).Columns(`id`, `name`).Values(1, `a`).
+			OnConflict(ConflictColumns(`id`), ResolveWithNewValues())
+		assertSQL(t, i, `MERGE INTO [users] WITH (HOLDLOCK) USING (VALUES (@p1, @p2)) AS [excluded] ([id], [name]) ON [users].[id] = [excluded].[id] `+
+			`WHEN MATCHED THEN UPDATE SET [name] = [excluded].[name] `+
+			`WHEN NOT MATCHED THEN INSERT ([id], [name]) VALUES ([excluded].[id], [excluded].[name]);`, 1, `a`)
+
+		i = b.Insert(nil, `users`).Columns(`tenant`, `email`, `name`).Values(1, `a@b.c`, `a`).
+			OnConflict(ConflictColumns(`tenant`, `email`), ResolveWith(func(u *UpdateSet) {
+				u.SetExcluded(`name`).Set(`version`, 2)
+			}))
+		assertSQL(t, i, `MERGE INTO [users] WITH (HOLDLOCK) USING (VALUES (@p1, @p2, @p3)) AS [excluded] ([tenant], [email], [name]) `+
+			`ON [users].[tenant] = [excluded].[tenant] AND [users].[email] = [excluded].[email] `+
+			`WHEN MATCHED THEN UPDATE SET [name] = [excluded].[name], [version] = @p4 `+
+			`WHEN NOT MATCHED THEN INSERT ([tenant], [email], [name]) VALUES ([excluded].[tenant], [excluded].[email], [excluded].[name]);`, 1, `a@b.c`, `a`, 2)
+
+		i = b.Insert(nil, `users`).Columns(`id`, `name`).Values(1, `a`).
+			OnConflict(ConflictColumns(`id`), DoNothing()).Returning(`id`, `name`)
+		assertSQL(t, i, `MERGE INTO [users] WITH (HOLDLOCK) USING (VALUES (@p1, @p2)) AS [excluded] ([id], [name]) ON [users].[id] = [excluded].[id] `+
+			`WHEN NOT MATCHED THEN INSERT ([id], [name]) VALUES ([excluded].[id], [excluded].[name]) OUTPUT INSERTED.[id], INSERTED.[name];`, 1, `a`)
+
+		// The conflict columns are set to themselves if there are no other columns.
+		i = b.Insert(nil, `users`).Columns(`id`).Values(1).OnConflict(ConflictColumns(`id`), ResolveWithIgnore())
+		assertSQL(t, i, `MERGE INTO [users] WITH (HOLDLOCK) USING (VALUES (@p1)) AS [excluded] ([id]) ON [users].[id] = [excluded].[id] `+
+			`WHEN MATCHED THEN UPDATE SET [id] = [users].[id] WHEN NOT MATCHED THEN INSERT ([id]) VALUES ([excluded].[id]);`, 1)
+
+		for _, opt := range []ConflictOption{ConflictConstraint(`users_pkey`), ConflictWhere(EQ(`deleted`, false))} {
+			i = b.Insert(nil, `users`).Columns(`id`).Values(1).OnConflict(ConflictColumns(`id`), opt, DoNothing())
+			if _, _, err := i.ToSQL(); err == nil {
+				t.Error("MERGE: expect an error for conflict constraints and predicates")
+			}
+		}
+	})
+	t.Run(`top`, func(t *testing.T) {
+		assertSQL(t, b.Update(nil, `users`).Set(`name`, `a`).Where(EQ(`age`, 1)).Limit(10),
+			`UPDATE TOP (10) [users] SET [name] = @p1 WHERE [age] = @p2`, `a`, 1)
+		assertSQL(t, b.Delete(nil, `users`).Where(EQ(`age`, 1)).Limit(10),
+			`DELETE TOP (10) FROM [users] WHERE [age] = @p1`, 1)
+		if _, _, err := b.Update(nil, `users`).Set(`name`, `a`).OrderBy(`id`).Limit(10).ToSQL(); err == nil {
+			t.Error("UPDATE TOP: expect an error for ORDER BY")
+		}
+	})
+	t.Run(`fetch`, func(t *testing.T) {
+		assertSQL(t, b.Select(nil).From(`users`).OrderBy(`id`).Limit(10).Offset(20),
+			`SELECT * FROM [users] ORDER BY [id] OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY`)
+		assertSQL(t, b.Select(nil).From(`users`).OrderBy(`id`).Limit(10),
+			`SELECT * FROM [users] ORDER BY [id] OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`)
+		// OFFSET requires an ORDER BY clause.
+		assertSQL(t, b.Select(nil).From(`users`).Where(EQ(`age`, 1)).Limit(10).Offset(20),
+			`SELECT * FROM [users] WHERE [age] = @p1 ORDER BY (SELECT NULL) OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY`, 1)
+		assertSQL(t, b.Select(nil).From(`users`).Offset(5),
+			`SELECT * FROM [users] ORDER BY (SELECT NULL) OFFSET 5 ROWS`)
+		if _, _, err := b.Select(nil).From(`users`).ForUpdate().ToSQL(); err == nil {
+			t.Error("SELECT FOR UPDATE: expect an error")
+		}
+	})
+	t.Run(`quote`, func(t *testing.T) {
+		assertSQL(t, b.Select(nil, `a]b`).From(`order]s`).Where(EQ(`x]]`, 1)),
+			`SELECT [a]]b] FROM [order]]s] WHERE [x]]]]] = @p1`, 1)
+		assertSQL(t, b.Select(nil, "COUNT(`a]b`)").From(`users`), `SELECT COUNT([a]]b]) FROM [users]`)
+		if got := b.Select(nil).unquote(`[a]]b]`); got != `a]b` {
+			t.Errorf("unquote([a]]b]) = %q, want %q", got, `a]b`)
+		}
+	})
+}
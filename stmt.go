@@ -0,0 +1,233 @@
+package leopards
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// stmtCache is an LRU cache of prepared statements keyed by their SQL.
+type stmtCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// cachedStmt is a prepared statement held by the cache. Evicted statements
+// are closed when they are no longer used by running executions.
+type cachedStmt struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// lookup returns the prepared statement of the query, or nil if it is not
+// cached. The returned statement must be released after use.
+func (c *stmtCache) lookup(query string) *cachedStmt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[query]
+	if !ok {
+		return nil
+	}
+	c.ll.MoveToFront(e)
+	cs := e.Value.(*cachedStmt)
+	cs.refs++
+	return cs
+}
+
+// get returns the prepared statement of the query, preparing it on cache misses.
+// The returned statement must be released after use.
+func (c *stmtCache) get(ctx context.Context, db *sql.DB, query string) (*cachedStmt, error) {
+	if cs := c.lookup(query); cs != nil {
+		return cs, nil
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Prepared concurrently by another execution.
+	if e, ok := c.items[query]; ok {
+		_ = stmt.Close()
+		c.ll.MoveToFront(e)
+		cs := e.Value.(*cachedStmt)
+		cs.refs++
+		return cs, nil
+	}
+	cs := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.items[query] = c.ll.PushFront(cs)
+	for c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+	return cs, nil
+}
+
+// release marks the end of an execution of the statement.
+func (c *stmtCache) release(cs *cachedStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cs.refs--; cs.refs == 0 && cs.evicted {
+		_ = cs.stmt.Close()
+	}
+}
+
+// evict removes the statement from the cache, for example, after it was
+// invalidated by the server.
+func (c *stmtCache) evict(cs *cachedStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[cs.query]; ok && e.Value == cs {
+		c.remove(e)
+	}
+}
+
+// remove removes an element from the cache. It must be called with the lock held.
+func (c *stmtCache) remove(e *list.Element) {
+	cs := c.ll.Remove(e).(*cachedStmt)
+	delete(c.items, cs.query)
+	cs.evicted = true
+	if cs.refs == 0 {
+		_ = cs.stmt.Close()
+	}
+}
+
+// close closes all statements of the cache.
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.ll.Len() > 0 {
+		c.remove(c.ll.Back())
+	}
+}
+
+// stale reports if the error was caused by a statement that is no longer
+// valid on the server (e.g. after the server restarted, or the connection
+// was recycled), and should be prepared again.
+func stale(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "Unknown prepared statement handler") || // MySQL (1243).
+		strings.Contains(msg, "prepared statement") && strings.Contains(msg, "does not exist") // PostgreSQL (26000).
+}
+
+// CacheStatements enables the cache of prepared statements with the given
+// capacity (0 disables it). Statements executed through the DB are prepared
+// once, keyed by their SQL, and the least recently used statements are closed
+// when the cache is full. Statements invalidated by the server are prepared
+// again. Transactions created by TX use the statements cached by the DB, and
+// execute the others without preparing them, as preparing a statement on the
+// DB may wait for a connection held by the transaction.
+//
+//	db.CacheStatements(200)
+//
+// Cached statements are prepared on the server for all dialects. For MySQL,
+// the client-side interpolation (interpolateParams) applies only to the
+// statements executed without the cache, and OpenOptions.DisableInterpolateParams
+// is not required.
+func (b *DB) CacheStatements(size int) {
+	if b.stmts != nil {
+		b.stmts.close()
+		b.stmts = nil
+	}
+	if size > 0 {
+		b.stmts = newStmtCache(size)
+	}
+}
+
+// Close closes the cached statements and the database.
+func (b *DB) Close() error {
+	if b.stmts != nil {
+		b.stmts.close()
+	}
+	if b.driver == nil {
+		return nil
+	}
+	return b.driver.Close()
+}
+
+// stmtExec executes a statement with the prepared statement cache.
+func (b *DB) stmtExec(ctx context.Context, statement string, args []any) (sql.Result, error) {
+	if b.tx != nil {
+		cs := b.stmts.lookup(statement)
+		if cs == nil {
+			return b.tx.ExecContext(ctx, statement, args...)
+		}
+		stmt := b.tx.StmtContext(ctx, cs.stmt)
+		res, err := stmt.ExecContext(ctx, args...)
+		_ = stmt.Close()
+		b.stmts.release(cs)
+		// Statements of transactions are bound to their connection, and are not retried.
+		if err != nil && stale(err) {
+			b.stmts.evict(cs)
+		}
+		return res, err
+	}
+	for retry := true; ; retry = false {
+		cs, err := b.stmts.get(ctx, b.driver, statement)
+		if err != nil {
+			return nil, err
+		}
+		res, err := cs.stmt.ExecContext(ctx, args...)
+		b.stmts.release(cs)
+		if err != nil && stale(err) {
+			b.stmts.evict(cs)
+			if retry {
+				continue
+			}
+		}
+		return res, err
+	}
+}
+
+// stmtQuery executes a query with the prepared statement cache.
+func (b *DB) stmtQuery(ctx context.Context, statement string, args []any) (*sql.Rows, error) {
+	if b.tx != nil {
+		cs := b.stmts.lookup(statement)
+		if cs == nil {
+			return b.tx.QueryContext(ctx, statement, args...)
+		}
+		stmt := b.tx.StmtContext(ctx, cs.stmt)
+		rows, err := stmt.QueryContext(ctx, args...)
+		// The statement of the transaction is closed without waiting for the
+		// end of the transaction. The connection of the transaction keeps the
+		// prepared statement of the open rows until it is released.
+		_ = stmt.Close()
+		b.stmts.release(cs)
+		if err != nil && stale(err) {
+			b.stmts.evict(cs)
+		}
+		return rows, err
+	}
+	for retry := true; ; retry = false {
+		cs, err := b.stmts.get(ctx, b.driver, statement)
+		if err != nil {
+			return nil, err
+		}
+		// The open rows keep the statement alive after its release.
+		rows, err := cs.stmt.QueryContext(ctx, args...)
+		b.stmts.release(cs)
+		if err != nil && stale(err) {
+			b.stmts.evict(cs)
+			if retry {
+				continue
+			}
+		}
+		return rows, err
+	}
+}
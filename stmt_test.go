@@ -0,0 +1,85 @@
+package leopards
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCacheStatements(t *testing.T) {
+	db := openSQLite(t, "CREATE TABLE `users` (`id` integer PRIMARY KEY, `name` text)")
+	db.CacheStatements(2)
+	ctx := context.Background()
+
+	for _, name := range []string{`a`, `b`, `c`} {
+		if _, err := db.Insert().Table(`users`).Columns(`name`).Values(name).Save(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := db.stmts.ll.Len(); n != 1 {
+		t.Errorf("cached statements = %d, want 1", n)
+	}
+	var names []string
+	if err := db.Query().Select(`name`).From(`users`).OrderBy(`id`).Scan(ctx, &names); err != nil || len(names) != 3 {
+		t.Fatalf("names = %v, %v", names, err)
+	}
+	var count int
+	if err := db.Raw(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil || count != 3 {
+		t.Fatalf("count = %d, %v", count, err)
+	}
+	// The least recently used statement (INSERT) was evicted.
+	if n := db.stmts.ll.Len(); n != 2 {
+		t.Errorf("cached statements = %d, want 2", n)
+	}
+	if _, ok := db.stmts.items["INSERT INTO `users` (`name`) VALUES (?)"]; ok {
+		t.Error("INSERT statement not evicted")
+	}
+
+	// Cached statements are prepared again on the connection of the transaction,
+	// and their rows are read after the statement is closed. The others are
+	// executed without preparing them, as the transaction holds the only
+	// connection of the DB.
+	tx, err := db.TX(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := tx.Update().Table(`users`).Set(`name`, `d`).Where(EQ(`id`, 1)).Save(ctx); err != nil {
+			t.Fatal(err)
+		}
+		names = names[:0]
+		if err := tx.Query().Select(`name`).From(`users`).OrderBy(`id`).Scan(ctx, &names); err != nil || len(names) != 3 || names[0] != `d` {
+			t.Fatalf("names in transaction = %v, %v", names, err)
+		}
+	}
+	if _, ok := db.stmts.items["UPDATE `users` SET `name` = ? WHERE `id` = ?"]; ok {
+		t.Error("UPDATE statement of the transaction cached")
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+	names = names[:0]
+	if err := db.Query().Select(`name`).From(`users`).OrderBy(`id`).Scan(ctx, &names); err != nil || names[0] != `a` {
+		t.Errorf("names after rollback = %v, %v", names, err)
+	}
+
+	// Statements in use are closed after their release.
+	cs, err := db.stmts.get(ctx, db.driver, `SELECT 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts := db.stmts
+	db.CacheStatements(0)
+	if !cs.evicted {
+		t.Error("statement in use not evicted")
+	}
+	if _, err := cs.stmt.Exec(); err != nil {
+		t.Errorf("Exec: %v", err)
+	}
+	stmts.release(cs)
+	if _, err := cs.stmt.Exec(); err == nil {
+		t.Error("Exec: expect an error for a closed statement")
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
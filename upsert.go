@@ -0,0 +1,310 @@
+package leopards
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// insertModel holds the model of an InsertBuilder created with Model.
+type insertModel struct {
+	fields []modelField
+	auto   *modelField   // autoIncrement field skipped by the insert.
+	value  reflect.Value // value of the autoIncrement field.
+}
+
+// setID sets the autoIncrement field of the model.
+func (m *insertModel) setID(id int64) {
+	if m.auto == nil || !m.value.CanSet() {
+		return
+	}
+	switch m.value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		m.value.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		m.value.SetUint(uint64(id))
+	}
+}
+
+// setLastInsertID sets the autoIncrement field of the model
+// from the result, if supported by the driver.
+func (m *insertModel) setLastInsertID(res sql.Result) {
+	if m.auto == nil || res == nil {
+		return
+	}
+	if id, err := res.LastInsertId(); err == nil && id > 0 {
+		m.setID(id)
+	}
+}
+
+// Upsert inserts the model, or updates the row conflicting with it on the given
+// columns (its primaryKey fields, or the primary key of its Schema, by default).
+// All columns of the model except the conflict and autoIncrement columns are
+// updated. The inserted result reports whether the row was inserted or updated.
+//
+//	inserted, err := db.Upsert(ctx, &user, `email`)
+func (b *DB) Upsert(ctx context.Context, v any, conflictColumns ...string) (inserted bool, err error) {
+	i := b.Insert().Model(v)
+	if i.model == nil {
+		return false, i.Err()
+	}
+	if len(conflictColumns) == 0 {
+		for _, f := range i.model.fields {
+			if f.has(`primaryKey`) {
+				conflictColumns = append(conflictColumns, f.column)
+			}
+		}
+	}
+	if len(conflictColumns) == 0 {
+		if s := modelTableSchema(v); s != nil {
+			conflictColumns = s.PrimaryKey
+		}
+	}
+	if len(conflictColumns) == 0 {
+		return false, errors.New("Upsert: missing conflict columns or primaryKey fields")
+	}
+	update := exclude(i.columns, conflictColumns)
+	action := DoNothing()
+	if len(update) > 0 {
+		action = ResolveWith(func(u *UpdateSet) {
+			for _, c := range update {
+				u.SetExcluded(c)
+			}
+		})
+	}
+	return i.OnConflict(ConflictColumns(conflictColumns...), action).Upsert(ctx)
+}
+
+// Upsert executes the `INSERT ... ON CONFLICT` statement configured with OnConflict,
+// and reports whether the row was inserted or updated. It supports a single row.
+//
+//   - MySQL: by the affected rows (1 for inserted rows, 2 for updated rows).
+//   - PostgreSQL: by `RETURNING (xmax = 0)`.
+//   - SQL Server: by `OUTPUT $action`.
+//   - SQLite: by checking if the conflicting row exists before the statement.
+//
+// If the builder was created with Model, the autoIncrement field is set from the
+// id of the row, if supported by the dialect. The columns set with Returning are
+// ignored by PostgreSQL and SQL Server, which return the row state instead.
+func (i *InsertBuilder) Upsert(ctx context.Context) (inserted bool, err error) {
+	switch {
+	case i.conflict == nil:
+		return false, errors.New("Upsert: missing OnConflict configuration")
+	case len(i.values) != 1:
+		return false, fmt.Errorf("Upsert: expect 1 row, got %d", len(i.values))
+	}
+	scoped, err := i.driver.resolveScopes(ctx, i.unscoped)
+	if err != nil {
+		return false, err
+	}
+	i.scoped = scoped
+
+	for _, iter := range i.driver.beforeInsert {
+		iter(i)
+	}
+
+	var res sql.Result
+	switch style := i.features().Upsert; {
+	case style == UpsertOnDuplicateKey:
+		res, inserted, err = i.upsertAffected(ctx)
+	case style == UpsertOnConflict && i.postgres():
+		inserted, err = i.upsertReturning(ctx)
+	case style == UpsertOnConflict:
+		res, inserted, err = i.upsertExists(ctx)
+	case style == UpsertMerge:
+		inserted, err = i.upsertOutput(ctx)
+	default:
+		err = fmt.Errorf("Upsert: not supported by dialect %q", i.dialect)
+	}
+	if err == nil {
+		i.driver.invalidate(ctx, i.table)
+	}
+
+	for _, iter := range i.driver.afterInsert {
+		iter(i, res)
+	}
+
+	return inserted, err
+}
+
+// upsertAffected executes the upsert, where one affected row
+// stands for an inserted row, and two for an updated row.
+func (i *InsertBuilder) upsertAffected(ctx context.Context) (sql.Result, bool, error) {
+	statement, args, err := i.ToSQL()
+	if err != nil {
+		return nil, false, err
+	}
+	res, err := i.driver.execContext(ctx, statement, args)
+	if err != nil {
+		return nil, false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return res, false, err
+	}
+	if n == 1 && i.model != nil {
+		i.model.setLastInsertID(res)
+	}
+	return res, n == 1, nil
+}
+
+// upsertReturning executes the upsert, where the `xmax` system
+// column of the returned row is zero for an inserted row.
+func (i *InsertBuilder) upsertReturning(ctx context.Context) (bool, error) {
+	returning := i.returning
+	defer func() { i.returning = returning }()
+	i.returning = nil
+	if i.model != nil && i.model.auto != nil {
+		i.returning = append(i.returning, i.model.auto.column)
+	}
+	i.returning = append(i.returning, `(xmax = 0)`)
+	statement, args, err := i.ToSQL()
+	if err != nil {
+		return false, err
+	}
+	var (
+		id       int64
+		inserted bool
+		dest     = []any{&inserted}
+	)
+	if len(i.returning) == 2 {
+		dest = []any{&id, &inserted}
+	}
+	// DO NOTHING does not return the existing row.
+	switch err = i.queryRow(ctx, statement, args, dest...); {
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	if len(dest) == 2 {
+		i.model.setID(id)
+	}
+	return inserted, nil
+}
+
+// upsertOutput executes the `MERGE` statement, where the
+// `$action` of the merged row is INSERT for an inserted row.
+func (i *InsertBuilder) upsertOutput(ctx context.Context) (bool, error) {
+	statement, args, err := i.mergeOutput()
+	if err != nil {
+		return false, err
+	}
+	var (
+		id     int64
+		action string
+		dest   = []any{&action}
+	)
+	if i.model != nil && i.model.auto != nil {
+		dest = []any{&id, &action}
+	}
+	// DO NOTHING does not output the existing row.
+	switch err = i.queryRow(ctx, statement, args, dest...); {
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	if len(dest) == 2 {
+		i.model.setID(id)
+	}
+	return action == "INSERT", nil
+}
+
+// mergeOutput returns the `MERGE` statement of the upsert, outputting the
+// autoIncrement column of the model (if any) and the `$action` of the row.
+// Like the RETURNING clause of PostgreSQL upserts, it replaces the columns
+// set with Returning, as the statement returns a single row.
+func (i *InsertBuilder) mergeOutput() (string, []any, error) {
+	returning := i.returning
+	defer func() { i.returning = returning }()
+	i.returning = nil
+	if i.model != nil && i.model.auto != nil {
+		i.returning = append(i.returning, i.model.auto.column)
+	}
+	statement, args, err := i.ToSQL()
+	if err != nil {
+		return "", nil, err
+	}
+	output := " OUTPUT $action;"
+	if len(i.returning) > 0 {
+		output = ", $action;"
+	}
+	return strings.TrimSuffix(statement, ";") + output, args, nil
+}
+
+// upsertExists checks if the conflicting row exists, and then executes the upsert.
+// The check and the statement are not atomic, unless they run in a transaction.
+func (i *InsertBuilder) upsertExists(ctx context.Context) (sql.Result, bool, error) {
+	columns, values := i.scopedValues()
+	preds := make([]*Predicate, 0, len(i.conflict.target.columns))
+	for _, c := range i.conflict.target.columns {
+		idx := indexOf(columns, c)
+		if idx == -1 {
+			return nil, false, fmt.Errorf("Upsert: conflict column %q is not inserted", c)
+		}
+		preds = append(preds, EQ(c, values[0][idx]))
+	}
+	exists, err := i.driver.Query().FromTable(Table(i.table).Schema(i.schema)).Where(And(preds...)).Unscoped().Exists(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	statement, args, err := i.ToSQL()
+	if err != nil {
+		return nil, false, err
+	}
+	res, err := i.driver.execContext(ctx, statement, args)
+	if err != nil {
+		return nil, false, err
+	}
+	if !exists && i.model != nil {
+		i.model.setLastInsertID(res)
+	}
+	return res, !exists, nil
+}
+
+// queryRow executes the statement and scans its first row into dest.
+func (i *InsertBuilder) queryRow(ctx context.Context, statement string, args []any, dest ...any) error {
+	rows, err := i.driver.queryContext(ctx, statement, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// exclude returns the strings of s that are not in ex.
+func exclude(s, ex []string) []string {
+	r := make([]string, 0, len(s))
+	for _, v := range s {
+		if !contains(ex, v) {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+func contains(s []string, v string) bool {
+	return indexOf(s, v) != -1
+}
+
+func indexOf(s []string, v string) int {
+	for i := range s {
+		if s[i] == v {
+			return i
+		}
+	}
+	return -1
+}
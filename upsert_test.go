@@ -0,0 +1,96 @@
+package leopards
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUpsertSQL(t *testing.T) {
+	upsert := func(dialect string) *InsertBuilder {
+		return Dialect(dialect).Insert(nil, `users`).Columns(`email`, `name`).Values(`a@b.c`, `a`).
+			OnConflict(ConflictColumns(`email`), ResolveWith(func(u *UpdateSet) { u.SetExcluded(`name`) }))
+	}
+	assertSQL(t, upsert(MySQL),
+		"INSERT INTO `users` (`email`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)", `a@b.c`, `a`)
+	assertSQL(t, upsert(Postgres),
+		`INSERT INTO "users" ("email", "name") VALUES ($1, $2) ON CONFLICT ("email") DO UPDATE SET "name" = "excluded"."name"`, `a@b.c`, `a`)
+	assertSQL(t, upsert(SQLite),
+		"INSERT INTO `users` (`email`, `name`) VALUES (?, ?) ON CONFLICT (`email`) DO UPDATE SET `name` = `excluded`.`name`", `a@b.c`, `a`)
+	// MySQL does not support DO NOTHING, and sets the columns to themselves.
+	assertSQL(t, Dialect(MySQL).Insert(nil, `users`).Columns(`email`).Values(`a@b.c`).OnConflict(DoNothing()),
+		"INSERT INTO `users` (`email`) VALUES (?) ON DUPLICATE KEY UPDATE `email` = `users`.`email`", `a@b.c`)
+
+	// The MySQL8 dialect refers to the inserted row by an alias, and uses the driver of MySQL.
+	if d, _ := GetDialect(MySQL8); d.DriverName() != MySQL {
+		t.Errorf("DriverName of MySQL8 = %q, want %q", d.DriverName(), MySQL)
+	}
+	assertSQL(t, upsert(MySQL8),
+		"INSERT INTO `users` (`email`, `name`) VALUES (?, ?) AS `new` ON DUPLICATE KEY UPDATE `name` = `new`.`name`", `a@b.c`, `a`)
+}
+
+func TestUpsertMergeOutput(t *testing.T) {
+	type user struct {
+		ID    int64  `leopard:"column:id;primaryKey;autoIncrement"`
+		Email string `json:"email"`
+	}
+	db := &DB{dialect: SQLServer}
+	merge := `MERGE INTO [user] WITH (HOLDLOCK) USING (VALUES (@p1)) AS [excluded] ([email]) ON [user].[email] = [excluded].[email] ` +
+		`WHEN MATCHED THEN UPDATE SET [email] = [user].[email] WHEN NOT MATCHED THEN INSERT ([email]) VALUES ([excluded].[email])`
+
+	i := db.Insert().Model(&user{Email: `a@b.c`}).OnConflict(ConflictColumns(`email`), ResolveWithIgnore())
+	statement, args, err := i.mergeOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := merge + ` OUTPUT INSERTED.[id], $action;`; statement != want {
+		t.Errorf("statement:\n got: %s\nwant: %s", statement, want)
+	}
+	if len(args) != 1 || args[0] != `a@b.c` {
+		t.Errorf("args = %v", args)
+	}
+
+	// The columns of Returning are replaced by the row state.
+	i = db.Insert().Table(`user`).Columns(`email`).Values(`a@b.c`).Returning(`email`).
+		OnConflict(ConflictColumns(`email`), ResolveWithIgnore())
+	if statement, _, err = i.mergeOutput(); err != nil {
+		t.Fatal(err)
+	}
+	if want := merge + ` OUTPUT $action;`; statement != want {
+		t.Errorf("statement:\n got: %s\nwant: %s", statement, want)
+	}
+	if len(i.returning) != 1 {
+		t.Errorf("returning = %v, want the columns of the builder", i.returning)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	type user struct {
+		ID    int64  `leopard:"column:id;primaryKey;autoIncrement"`
+		Email string `json:"email"`
+		Name  string `json:"name"`
+	}
+	db := openSQLite(t, "CREATE TABLE `user` (`id` integer PRIMARY KEY AUTOINCREMENT, `email` text UNIQUE, `name` text)")
+	ctx := context.Background()
+
+	u := &user{Email: `a@b.c`, Name: `a`}
+	inserted, err := db.Upsert(ctx, u, `email`)
+	if err != nil || !inserted || u.ID != 1 {
+		t.Fatalf("Upsert = %v, %v, id %d, want inserted row 1", inserted, err, u.ID)
+	}
+	inserted, err = db.Upsert(ctx, &user{Email: `a@b.c`, Name: `b`}, `email`)
+	if err != nil || inserted {
+		t.Fatalf("Upsert = %v, %v, want updated row", inserted, err)
+	}
+	var names []string
+	if err := db.Query().Select(`name`).From(`user`).Scan(ctx, &names); err != nil || len(names) != 1 || names[0] != `b` {
+		t.Errorf("names = %v, %v, want [b]", names, err)
+	}
+
+	if _, err := db.Insert().Table(`user`).Columns(`email`).Values(`a`).Values(`b`).
+		OnConflict(ConflictColumns(`email`), DoNothing()).Upsert(ctx); err == nil {
+		t.Error("Upsert: expect an error for multiple rows")
+	}
+	if _, err := db.Insert().Table(`user`).Columns(`email`).Values(`a`).Upsert(ctx); err == nil {
+		t.Error("Upsert: expect an error without OnConflict")
+	}
+}